@@ -0,0 +1,482 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package address builds, encodes and decodes Shelley era Cardano
+// addresses; Byron bootstrap addresses are recognized, but kept opaque.
+package address
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math/big"
+
+	"github.com/SundaeSwap-finance/kugo/plutusdata"
+)
+
+// Network is the network id carried in an address header
+type Network byte
+
+const (
+	Testnet Network = 0
+	Mainnet Network = 1
+)
+
+// CredentialKind distinguishes key hashes from script hashes
+type CredentialKind int
+
+const (
+	KeyHash CredentialKind = iota
+	ScriptHash
+)
+
+// HashSize is the size of key and script hashes
+const HashSize = 28
+
+// Credential is a payment or stake credential
+type Credential struct {
+	Kind CredentialKind
+	Hash []byte
+}
+
+// KeyCredential is a credential for the blake2b-224 hash of a public key
+func KeyCredential(hash []byte) Credential {
+	return Credential{Kind: KeyHash, Hash: hash}
+}
+
+// ScriptCredential is a credential for a script hash
+func ScriptCredential(hash []byte) Credential {
+	return Credential{Kind: ScriptHash, Hash: hash}
+}
+
+// String returns the hex encoded hash
+func (c Credential) String() string {
+	return hex.EncodeToString(c.Hash)
+}
+
+func (c Credential) validate() error {
+	if len(c.Hash) != HashSize {
+		return fmt.Errorf(
+			"credential hash must be %v bytes, got %v",
+			HashSize,
+			len(c.Hash),
+		)
+	}
+	return nil
+}
+
+// Pointer locates a stake registration certificate on chain
+type Pointer struct {
+	Slot      uint64
+	TxIndex   uint64
+	CertIndex uint64
+}
+
+// Type of address, as encoded in the header
+type Type int
+
+const (
+	TypeUnknown Type = iota
+	// TypeBase has a payment and a stake credential
+	TypeBase
+	// TypePointer has a payment credential and a pointer to a stake
+	// registration
+	TypePointer
+	// TypeEnterprise has only a payment credential
+	TypeEnterprise
+	// TypeReward (stake address) has only a stake credential
+	TypeReward
+	// TypeByron is a base58 encoded bootstrap address; its contents are
+	// only available as Raw bytes
+	TypeByron
+)
+
+func (t Type) String() string {
+	switch t {
+	case TypeBase:
+		return "base"
+	case TypePointer:
+		return "pointer"
+	case TypeEnterprise:
+		return "enterprise"
+	case TypeReward:
+		return "reward"
+	case TypeByron:
+		return "byron"
+	default:
+		return "unknown"
+	}
+}
+
+// Address is a decoded address; Payment is unset for reward and Byron
+// addresses, Stake is set for base and reward addresses, Pointer for
+// pointer addresses and Raw for Byron addresses
+type Address struct {
+	Type    Type
+	Network Network
+	Payment Credential
+	Stake   *Credential
+	Pointer *Pointer
+	Raw     []byte
+}
+
+// NewEnterprise builds an address with no stake rights
+func NewEnterprise(network Network, payment Credential) Address {
+	return Address{Type: TypeEnterprise, Network: network, Payment: payment}
+}
+
+// NewBase builds an address delegating to the stake credential
+func NewBase(network Network, payment, stake Credential) Address {
+	return Address{
+		Type:    TypeBase,
+		Network: network,
+		Payment: payment,
+		Stake:   &stake,
+	}
+}
+
+// NewPointer builds an address delegating via a stake registration pointer
+func NewPointer(network Network, payment Credential, pointer Pointer) Address {
+	return Address{
+		Type:    TypePointer,
+		Network: network,
+		Payment: payment,
+		Pointer: &pointer,
+	}
+}
+
+// NewReward builds a stake address
+func NewReward(network Network, stake Credential) Address {
+	return Address{Type: TypeReward, Network: network, Stake: &stake}
+}
+
+// Prefix returns the bech32 human readable prefix of the address
+func (a Address) Prefix() string {
+	prefix := "addr"
+	if a.Type == TypeReward {
+		prefix = "stake"
+	}
+	if a.Network != Mainnet {
+		prefix += "_test"
+	}
+	return prefix
+}
+
+// Bytes returns the raw address bytes
+func (a Address) Bytes() ([]byte, error) {
+	if a.Type == TypeByron {
+		if _, err := parseByron(a.Raw); err != nil {
+			return nil, err
+		}
+		return a.Raw, nil
+	}
+	if a.Network > 0x0f {
+		return nil, fmt.Errorf("invalid network id %v", a.Network)
+	}
+
+	var header byte
+	switch a.Type {
+	case TypeBase:
+		if a.Stake == nil {
+			return nil, errors.New("base address requires a stake credential")
+		}
+		if a.Stake.Kind == ScriptHash {
+			header |= 0x20
+		}
+	case TypePointer:
+		if a.Pointer == nil {
+			return nil, errors.New("pointer address requires a pointer")
+		}
+		header = 0x40
+	case TypeEnterprise:
+		header = 0x60
+	case TypeReward:
+		if a.Stake == nil {
+			return nil, errors.New("reward address requires a stake credential")
+		}
+		header = 0xe0
+		if a.Stake.Kind == ScriptHash {
+			header |= 0x10
+		}
+		if err := a.Stake.validate(); err != nil {
+			return nil, err
+		}
+		data := append([]byte{header | byte(a.Network)}, a.Stake.Hash...)
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unable to encode %v address", a.Type)
+	}
+
+	if err := a.Payment.validate(); err != nil {
+		return nil, err
+	}
+	if a.Payment.Kind == ScriptHash {
+		header |= 0x10
+	}
+	data := append([]byte{header | byte(a.Network)}, a.Payment.Hash...)
+	switch a.Type {
+	case TypeBase:
+		if err := a.Stake.validate(); err != nil {
+			return nil, err
+		}
+		data = append(data, a.Stake.Hash...)
+	case TypePointer:
+		data = appendNatural(data, a.Pointer.Slot)
+		data = appendNatural(data, a.Pointer.TxIndex)
+		data = appendNatural(data, a.Pointer.CertIndex)
+	}
+	return data, nil
+}
+
+// Bech32 returns the bech32 encoding of the address; Byron addresses have
+// no bech32 encoding
+func (a Address) Bech32() (string, error) {
+	if a.Type == TypeByron {
+		return "", errors.New("byron addresses have no bech32 encoding")
+	}
+	data, err := a.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return encodeBech32(a.Prefix(), data), nil
+}
+
+// String returns the bech32 encoding of the address, base58 for Byron
+// addresses, or an empty string if the address is invalid
+func (a Address) String() string {
+	if a.Type == TypeByron {
+		data, err := a.Bytes()
+		if err != nil {
+			return ""
+		}
+		return encodeBase58(data)
+	}
+	s, _ := a.Bech32()
+	return s
+}
+
+// Parse decodes a bech32 encoded address, or a base58 encoded Byron address
+func Parse(s string) (Address, error) {
+	prefix, data, err := DecodeBech32(s)
+	if err != nil {
+		if raw, base58Err := decodeBase58(s); base58Err == nil &&
+			len(raw) > 0 &&
+			raw[0]>>4 == 8 {
+			return FromBytes(raw)
+		}
+		return Address{}, fmt.Errorf("invalid address %v: %w", s, err)
+	}
+	a, err := FromBytes(data)
+	if err != nil {
+		return Address{}, err
+	}
+	if a.Prefix() != prefix {
+		return Address{}, fmt.Errorf(
+			"address prefix %v doesn't match a %v address",
+			prefix,
+			a.Type,
+		)
+	}
+	return a, nil
+}
+
+// FromBytes decodes raw address bytes
+func FromBytes(data []byte) (Address, error) {
+	if len(data) == 0 {
+		return Address{}, errors.New("empty address")
+	}
+	header, body := data[0], data[1:]
+	a := Address{Network: Network(header & 0x0f)}
+
+	credential := func(script bool, hash []byte) Credential {
+		c := Credential{Kind: KeyHash, Hash: append([]byte{}, hash...)}
+		if script {
+			c.Kind = ScriptHash
+		}
+		return c
+	}
+
+	kind := header >> 4
+	switch {
+	case kind <= 3:
+		if len(body) != 2*HashSize {
+			return Address{}, fmt.Errorf(
+				"base address must be %v bytes, got %v",
+				1+2*HashSize,
+				len(data),
+			)
+		}
+		a.Type = TypeBase
+		a.Payment = credential(kind&1 == 1, body[:HashSize])
+		stake := credential(kind&2 == 2, body[HashSize:])
+		a.Stake = &stake
+
+	case kind == 4 || kind == 5:
+		if len(body) < HashSize {
+			return Address{}, errors.New("pointer address is too short")
+		}
+		a.Type = TypePointer
+		a.Payment = credential(kind == 5, body[:HashSize])
+		var pointer Pointer
+		rest := body[HashSize:]
+		fields := []*uint64{&pointer.Slot, &pointer.TxIndex, &pointer.CertIndex}
+		for _, field := range fields {
+			value, n, err := readNatural(rest)
+			if err != nil {
+				return Address{}, fmt.Errorf("invalid pointer: %w", err)
+			}
+			*field, rest = value, rest[n:]
+		}
+		if len(rest) != 0 {
+			return Address{}, errors.New(
+				"unexpected trailing bytes after pointer",
+			)
+		}
+		a.Pointer = &pointer
+
+	case kind == 6 || kind == 7:
+		if len(body) != HashSize {
+			return Address{}, fmt.Errorf(
+				"enterprise address must be %v bytes, got %v",
+				1+HashSize,
+				len(data),
+			)
+		}
+		a.Type = TypeEnterprise
+		a.Payment = credential(kind == 7, body)
+
+	case kind == 8:
+		network, err := parseByron(data)
+		if err != nil {
+			return Address{}, err
+		}
+		a.Type = TypeByron
+		a.Network = network
+		a.Raw = append([]byte{}, data...)
+
+	case kind == 14 || kind == 15:
+		if len(body) != HashSize {
+			return Address{}, fmt.Errorf(
+				"reward address must be %v bytes, got %v",
+				1+HashSize,
+				len(data),
+			)
+		}
+		a.Type = TypeReward
+		stake := credential(kind == 15, body)
+		a.Stake = &stake
+
+	default:
+		return Address{}, fmt.Errorf(
+			"unsupported address header 0x%02x",
+			header,
+		)
+	}
+	return a, nil
+}
+
+// parseByron checks the envelope of a Byron address, [#6.24(payload),
+// crc32], and reports whether its attributes name a non-mainnet network
+func parseByron(data []byte) (Network, error) {
+	if len(data) < 4 || data[0] != 0x82 || data[1] != 0xd8 || data[2] != 0x18 {
+		return 0, errors.New("invalid byron address")
+	}
+	rest := data[3:]
+	var length uint64
+	switch {
+	case len(rest) > 0 && rest[0] >= 0x40 && rest[0] < 0x58:
+		length, rest = uint64(rest[0]-0x40), rest[1:]
+	case len(rest) > 1 && rest[0] == 0x58:
+		length, rest = uint64(rest[1]), rest[2:]
+	case len(rest) > 2 && rest[0] == 0x59:
+		length, rest = uint64(binary.BigEndian.Uint16(rest[1:])), rest[3:]
+	default:
+		return 0, errors.New("invalid byron address payload")
+	}
+	if uint64(len(rest)) < length {
+		return 0, errors.New("truncated byron address")
+	}
+	payload, rest := rest[:length], rest[length:]
+
+	checksum, err := plutusdata.Decode(rest)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byron address checksum: %w", err)
+	}
+	crc, ok := checksum.(plutusdata.Integer)
+	if !ok || !crc.Value.IsUint64() ||
+		crc.Value.Uint64() != uint64(crc32.ChecksumIEEE(payload)) {
+		return 0, errors.New("byron address checksum mismatch")
+	}
+
+	// The payload is [root, attributes, type]; attribute 2 holds the
+	// protocol magic of networks other than mainnet
+	node, err := plutusdata.Decode(payload)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byron address payload: %w", err)
+	}
+	fields, ok := node.(plutusdata.List)
+	if !ok || len(fields.Items) != 3 {
+		return 0, errors.New("invalid byron address payload")
+	}
+	attributes, ok := fields.Items[1].(plutusdata.Map)
+	if !ok {
+		return 0, errors.New("invalid byron address attributes")
+	}
+	for _, pair := range attributes.Pairs {
+		if key, ok := pair.Key.(plutusdata.Integer); ok &&
+			key.Value.Cmp(big.NewInt(2)) == 0 {
+			return Testnet, nil
+		}
+	}
+	return Mainnet, nil
+}
+
+// appendNatural writes a pointer component: big endian 7 bit groups, with
+// the high bit set on all but the last
+func appendNatural(data []byte, value uint64) []byte {
+	var groups []byte
+	groups = append(groups, byte(value&0x7f))
+	for value >>= 7; value > 0; value >>= 7 {
+		groups = append(groups, byte(value&0x7f)|0x80)
+	}
+	for i := len(groups) - 1; i >= 0; i-- {
+		data = append(data, groups[i])
+	}
+	return data
+}
+
+func readNatural(data []byte) (uint64, int, error) {
+	var value uint64
+	for i, b := range data {
+		if value > (1<<64-1)>>7 {
+			return 0, 0, errors.New("natural overflows")
+		}
+		value = value<<7 | uint64(b&0x7f)
+		if b&0x80 == 0 {
+			return value, i + 1, nil
+		}
+	}
+	return 0, 0, errors.New("truncated natural")
+}
@@ -0,0 +1,167 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package address
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+// Test vectors from CIP-19
+func TestAddress_CIP19(t *testing.T) {
+	mustHex := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		assert.Nil(t, err)
+		return b
+	}
+	paymentKey := KeyCredential(
+		mustHex("9493315cd92eb5d8c4304e67b7e16ae36d61d34502694657811a2c8e"),
+	)
+	stakeKey := KeyCredential(
+		mustHex("337b62cfff6403a06a3acbc34f8c46003c69fe79a3628cefa9c47251"),
+	)
+	script := ScriptCredential(
+		mustHex("c37b1b5dc0669f1d3c61a6fddb2e8fde96be87b881c60bce8e8d542f"),
+	)
+	pointer := Pointer{Slot: 2498243, TxIndex: 27, CertIndex: 3}
+
+	testCases := map[string]struct {
+		Address Address
+		Want    string
+	}{
+		"base key/key": {
+			NewBase(Mainnet, paymentKey, stakeKey),
+			"addr1qx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3n0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgse35a3x",
+		},
+		"base script/key": {
+			NewBase(Mainnet, script, stakeKey),
+			"addr1z8phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gten0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgs9yc0hh",
+		},
+		"base key/script": {
+			NewBase(Mainnet, paymentKey, script),
+			"addr1yx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzerkr0vd4msrxnuwnccdxlhdjar77j6lg0wypcc9uar5d2shs2z78ve",
+		},
+		"base script/script": {
+			NewBase(Mainnet, script, script),
+			"addr1x8phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gt7r0vd4msrxnuwnccdxlhdjar77j6lg0wypcc9uar5d2shskhj42g",
+		},
+		"pointer key": {
+			NewPointer(Mainnet, paymentKey, pointer),
+			"addr1gx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer5pnz75xxcrzqf96k",
+		},
+		"pointer script": {
+			NewPointer(Mainnet, script, pointer),
+			"addr128phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gtupnz75xxcrtw79hu",
+		},
+		"enterprise key": {
+			NewEnterprise(Mainnet, paymentKey),
+			"addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl8",
+		},
+		"enterprise script": {
+			NewEnterprise(Mainnet, script),
+			"addr1w8phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gtcyjy7wx",
+		},
+		"reward key": {
+			NewReward(Mainnet, stakeKey),
+			"stake1uyehkck0lajq8gr28t9uxnuvgcqrc6070x3k9r8048z8y5gh6ffgw",
+		},
+		"reward script": {
+			NewReward(Mainnet, script),
+			"stake178phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gtcccycj5",
+		},
+		"testnet enterprise": {
+			NewEnterprise(Testnet, paymentKey),
+			"addr_test1vz2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzerspjrlsz",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tc.Address.Bech32()
+			assert.Nil(t, err)
+			assert.Equal(t, tc.Want, got)
+
+			parsed, err := Parse(tc.Want)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.Address, parsed)
+
+			raw, err := tc.Address.Bytes()
+			assert.Nil(t, err)
+			fromBytes, err := FromBytes(raw)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.Address, fromBytes)
+		})
+	}
+}
+
+func TestAddress_Invalid(t *testing.T) {
+	_, err := NewEnterprise(Mainnet, KeyCredential([]byte{1, 2, 3})).Bech32()
+	assert.NotNil(t, err)
+	assert.Equal(t, "", NewEnterprise(Mainnet, KeyCredential(nil)).String())
+
+	for _, s := range []string{
+		"",
+		"addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl9",  // checksum
+		"stake1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl8", // prefix
+		"addr_test1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl8",
+		"Addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl8",
+	} {
+		_, err := Parse(s)
+		assert.NotNil(t, err, s)
+	}
+
+	_, err = FromBytes([]byte{0x61, 0x01})
+	assert.NotNil(t, err)
+	_, err = FromBytes([]byte{0x81})
+	assert.NotNil(t, err)
+}
+
+func TestAddress_Byron(t *testing.T) {
+	for s, network := range map[string]Network{
+		"Ae2tdPwUPEZFRbyhz3cpfC2CumGzNkFBN2L42rcUc2yjQpEkxDbkPodpMAi": Mainnet,
+		// carries the testnet protocol magic in its attributes
+		"37btjrVyb4KDXBNC4haBVPCrro8AQPHwvCMp3RFhhSVWwfFmZ6wwzSK6JK1hY6wHNmtrpTf1kdbva8TCneM2YsiXT7mrzT21EacHnPpz5YyUdj64na": Testnet,
+	} {
+		a, err := Parse(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, TypeByron, a.Type)
+		assert.Equal(t, network, a.Network)
+		assert.Equal(t, s, a.String())
+		_, err = a.Bech32()
+		assert.NotNil(t, err)
+	}
+
+	// Corrupting the checksum is detected
+	a, err := Parse(
+		"Ae2tdPwUPEZFRbyhz3cpfC2CumGzNkFBN2L42rcUc2yjQpEkxDbkPodpMAi",
+	)
+	assert.Nil(t, err)
+	raw := append([]byte{}, a.Raw...)
+	raw[len(raw)-1] ^= 1
+	_, err = FromBytes(raw)
+	assert.NotNil(t, err)
+}
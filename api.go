@@ -0,0 +1,61 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo
+
+import (
+	"context"
+	"iter"
+)
+
+// API is the set of kupo queries a Client makes; depend on it, rather than
+// *Client, to substitute fakes or wrap the client with decorators such as
+// those in the middleware package. Helpers like DatumInto, ResolveDatums,
+// Watch, WaitUntilSynced, NewMatchesPaginator and NewChainTracker take an
+// API, so they work over any of these; PollOption configures the ones that
+// poll.
+type API interface {
+	Matches(ctx context.Context, filters ...MatchesFilter) ([]Match, error)
+	MatchesStream(
+		ctx context.Context,
+		filters ...MatchesFilter,
+	) iter.Seq2[Match, error]
+	Patterns(ctx context.Context) ([]string, error)
+	AddPattern(
+		ctx context.Context,
+		pattern PatternSpec,
+		opts ...PatternOption,
+	) ([]string, error)
+	RemovePattern(ctx context.Context, pattern PatternSpec) (int, error)
+	PatternIncluded(ctx context.Context, pattern PatternSpec) (bool, error)
+	Checkpoints(
+		ctx context.Context,
+		filters ...CheckpointsFilter,
+	) ([]Point, error)
+	Datum(ctx context.Context, datumHash string) (string, error)
+	Script(ctx context.Context, scriptHash string) (*Script, error)
+	Metadata(ctx context.Context, slotNo int, txId string) ([]Metadatum, error)
+	Health(ctx context.Context) (*Health, error)
+}
+
+var _ API = (*Client)(nil)
@@ -0,0 +1,322 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/SundaeSwap-finance/ogmigo/v6"
+)
+
+// Rollback describes a fork detected by a ChainTracker
+type Rollback struct {
+	// ForkPoint is the most recent known point that is still on the chain;
+	// anything observed after ForkPoint.SlotNo should be considered invalid.
+	// It is the zero Point if no common ancestor is known.
+	ForkPoint Point
+	// Invalidated lists the previously observed points that are no longer on
+	// the chain, oldest first
+	Invalidated []Point
+}
+
+// RollbackFunc is notified of each rollback detected by a ChainTracker
+type RollbackFunc func(rollback Rollback)
+
+// ChainTracker periodically samples kupo's checkpoints and compares them to
+// the points it has seen before, to detect rollbacks
+type ChainTracker struct {
+	api       API
+	maxPoints int
+	options   pollOptions
+
+	// observeMutex serializes comparisons, while mutex guards the fields
+	// below so they can be read during a (possibly slow) Poll
+	observeMutex sync.Mutex
+	mutex        sync.Mutex
+	points       []Point // oldest first
+	subscribers  map[int]RollbackFunc
+	nextID       int
+}
+
+// NewChainTracker returns a tracker remembering up to maxPoints of the most
+// recent points it has observed; if maxPoints is 0, 256 points are kept.
+// Tracking a *Client polls at its WithPollInterval and logs to its logger,
+// unless opts say otherwise.
+func NewChainTracker(
+	api API,
+	maxPoints int,
+	opts ...PollOption,
+) *ChainTracker {
+	if maxPoints <= 0 {
+		maxPoints = 256
+	}
+	if client, ok := api.(*Client); ok {
+		opts = append(client.pollOptions(), opts...)
+	}
+	return &ChainTracker{
+		api:         api,
+		maxPoints:   maxPoints,
+		options:     buildPollOptions(opts...),
+		subscribers: map[int]RollbackFunc{},
+	}
+}
+
+// Subscribe registers fn to be called with each rollback, returning a func
+// to unsubscribe
+func (t *ChainTracker) Subscribe(fn RollbackFunc) (unsubscribe func()) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	id := t.nextID
+	t.nextID++
+	t.subscribers[id] = fn
+	return func() {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+		delete(t.subscribers, id)
+	}
+}
+
+// Points returns the points currently being tracked, oldest first
+func (t *ChainTracker) Points() []Point {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return append([]Point(nil), t.points...)
+}
+
+// Tip returns the most recent point observed, if any
+func (t *ChainTracker) Tip() (Point, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if len(t.points) == 0 {
+		return Point{}, false
+	}
+	return t.points[len(t.points)-1], true
+}
+
+// Poll fetches kupo's recent checkpoints once, notifying subscribers and
+// returning the rollback if one occurred since the last poll
+func (t *ChainTracker) Poll(ctx context.Context) (*Rollback, error) {
+	recent, err := t.api.Checkpoints(ctx, Recent())
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch checkpoints: %w", err)
+	}
+
+	// Points that fall between the sampled checkpoints are checked
+	// individually; kupo returns the closest checkpoint at or before a slot,
+	// so anything else means the block is gone
+	verify := func(point Point) (bool, error) {
+		points, err := t.api.Checkpoints(ctx, BySlot(uint64(point.SlotNo)))
+		if err != nil {
+			return false, fmt.Errorf(
+				"unable to fetch checkpoint %v: %w",
+				point.SlotNo,
+				err,
+			)
+		}
+		return len(points) == 1 && points[0] == point, nil
+	}
+
+	rollback, err := t.observe(recent, verify)
+	if err != nil {
+		return nil, err
+	}
+	if rollback != nil {
+		t.options.logger.Info(
+			"rollback detected",
+			ogmigo.KV("fork_slot", fmt.Sprintf("%v", rollback.ForkPoint.SlotNo)),
+			ogmigo.KV("fork_hash", rollback.ForkPoint.HeaderHash),
+			ogmigo.KV("invalidated", fmt.Sprintf("%v", len(rollback.Invalidated))),
+		)
+	}
+	return rollback, nil
+}
+
+// Run polls kupo at the tracker's poll interval until ctx is done; errors
+// are logged and polling continues
+func (t *ChainTracker) Run(ctx context.Context) error {
+	ticker := time.NewTicker(t.options.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := t.Poll(ctx); err != nil && ctx.Err() == nil {
+			t.options.logger.Info(
+				"unable to poll checkpoints",
+				ogmigo.KV("err", err.Error()),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Observe compares a sample of points from the current chain, which must
+// include its tip, against the points seen before, notifying subscribers and
+// returning the rollback if any previously observed point is no longer on the
+// chain. Unlike Poll, observed points that fall between the sampled points
+// are assumed to still be on the chain. It is exported so that points
+// learned elsewhere can be fed to the tracker.
+func (t *ChainTracker) Observe(current ...Point) *Rollback {
+	rollback, _ := t.observe(current, nil)
+	return rollback
+}
+
+// observe implements Observe, using verify (when provided) to check
+// observed points that can't be confirmed or refuted from the sample
+func (t *ChainTracker) observe(
+	current []Point,
+	verify func(Point) (bool, error),
+) (*Rollback, error) {
+	if len(current) == 0 {
+		return nil, nil
+	}
+	t.observeMutex.Lock()
+	defer t.observeMutex.Unlock()
+
+	current = append([]Point(nil), current...)
+	sort.Slice(current, func(i, j int) bool {
+		return current[i].SlotNo < current[j].SlotNo
+	})
+	oldest, tip := current[0], current[len(current)-1]
+	bySlot := make(map[int]string, len(current))
+	for _, point := range current {
+		bySlot[point.SlotNo] = point.HeaderHash
+	}
+
+	observed := t.Points()
+
+	// A point is known to be rolled back if kupo now has a different block
+	// at that slot, or its tip has moved back before that slot. Since the
+	// chain is linear, once one point is rolled back so is every later one.
+	invalidFrom := len(observed)
+	for i, point := range observed {
+		hash, ok := bySlot[point.SlotNo]
+		if (ok && hash != point.HeaderHash) || point.SlotNo > tip.SlotNo {
+			invalidFrom = i
+			break
+		}
+	}
+
+	// Points within the sampled range that aren't in the sample are
+	// ambiguous; binary search for the first that is no longer on the chain
+	if verify != nil {
+		valid := func(point Point) (bool, error) {
+			if point.SlotNo < oldest.SlotNo {
+				return true, nil
+			}
+			if _, ok := bySlot[point.SlotNo]; ok {
+				return true, nil
+			}
+			return verify(point)
+		}
+		lo, hi := 0, invalidFrom
+		for lo < hi {
+			mid := lo + (hi-lo)/2
+			ok, err := valid(observed[mid])
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
+		}
+		invalidFrom = lo
+	}
+
+	var rollback *Rollback
+	if invalidFrom < len(observed) {
+		rollback = &Rollback{
+			Invalidated: append([]Point(nil), observed[invalidFrom:]...),
+		}
+		invalidSlot := observed[invalidFrom].SlotNo
+		// The fork point is the most recent point known to be on the chain
+		// before the first invalidated point
+		if invalidFrom > 0 {
+			rollback.ForkPoint = observed[invalidFrom-1]
+		}
+		for _, point := range current {
+			if point.SlotNo >= invalidSlot {
+				break
+			}
+			if point.SlotNo > rollback.ForkPoint.SlotNo {
+				rollback.ForkPoint = point
+			}
+		}
+		observed = observed[:invalidFrom]
+	}
+
+	observed = mergePoints(observed, current)
+	if len(observed) > t.maxPoints {
+		observed = observed[len(observed)-t.maxPoints:]
+	}
+
+	t.mutex.Lock()
+	t.points = observed
+	var subscribers []RollbackFunc
+	if rollback != nil {
+		for _, fn := range t.subscribers {
+			subscribers = append(subscribers, fn)
+		}
+	}
+	t.mutex.Unlock()
+
+	for _, fn := range subscribers {
+		fn(*rollback)
+	}
+	return rollback, nil
+}
+
+// mergePoints combines two slices of points sorted by slot, preferring
+// points from b when both have a point at the same slot
+func mergePoints(a, b []Point) []Point {
+	merged := make([]Point, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j == len(b) || (i < len(a) && a[i].SlotNo < b[j].SlotNo):
+			merged = append(merged, a[i])
+			i++
+		case i == len(a) || b[j].SlotNo < a[i].SlotNo:
+			merged = append(merged, b[j])
+			j++
+		default:
+			merged = append(merged, b[j])
+			i++
+			j++
+		}
+	}
+	return merged
+}
@@ -0,0 +1,117 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SundaeSwap-finance/kugo"
+	"github.com/SundaeSwap-finance/kugo/kugotest"
+	"github.com/tj/assert"
+)
+
+func Test_ChainTracker(t *testing.T) {
+	t.Parallel()
+	fake := kugotest.New().
+		RollForward(kugo.Point{SlotNo: 10, HeaderHash: "a"}).
+		RollForward(kugo.Point{SlotNo: 20, HeaderHash: "b"}).
+		RollForward(kugo.Point{SlotNo: 30, HeaderHash: "c"})
+	server := fake.HTTP()
+	defer server.Close()
+
+	ctx := context.Background()
+	tracker := kugo.NewChainTracker(kugo.New(kugo.WithEndpoint(server.URL)), 0)
+	var notified []kugo.Rollback
+	unsubscribe := tracker.Subscribe(func(rollback kugo.Rollback) {
+		notified = append(notified, rollback)
+	})
+
+	rollback, err := tracker.Poll(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, rollback)
+	tip, ok := tracker.Tip()
+	assert.True(t, ok)
+	assert.Equal(t, kugo.Point{SlotNo: 30, HeaderHash: "c"}, tip)
+
+	// The chain extends
+	fake.
+		RollForward(kugo.Point{SlotNo: 35, HeaderHash: "x"}).
+		RollForward(kugo.Point{SlotNo: 40, HeaderHash: "d"})
+	rollback, err = tracker.Poll(ctx)
+	assert.Nil(t, err)
+	assert.Nil(t, rollback)
+
+	// A fork replaces everything after 20, so 30, 35 and 40 are gone
+	fake.
+		RollBackward(20).
+		RollForward(kugo.Point{SlotNo: 45, HeaderHash: "f'"})
+	rollback, err = tracker.Poll(ctx)
+	assert.Nil(t, err)
+	expected := kugo.Rollback{
+		ForkPoint: kugo.Point{SlotNo: 20, HeaderHash: "b"},
+		Invalidated: []kugo.Point{
+			{SlotNo: 30, HeaderHash: "c"},
+			{SlotNo: 35, HeaderHash: "x"},
+			{SlotNo: 40, HeaderHash: "d"},
+		},
+	}
+	assert.Equal(t, &expected, rollback)
+	assert.Equal(t, []kugo.Rollback{expected}, notified)
+	assert.Equal(
+		t,
+		[]kugo.Point{
+			{SlotNo: 10, HeaderHash: "a"},
+			{SlotNo: 20, HeaderHash: "b"},
+			{SlotNo: 45, HeaderHash: "f'"},
+		},
+		tracker.Points(),
+	)
+
+	// The tip moving backwards is also a rollback
+	unsubscribe()
+	rollback = tracker.Observe(kugo.Point{SlotNo: 10, HeaderHash: "a"})
+	assert.Equal(
+		t,
+		&kugo.Rollback{
+			ForkPoint: kugo.Point{SlotNo: 10, HeaderHash: "a"},
+			Invalidated: []kugo.Point{
+				{SlotNo: 20, HeaderHash: "b"},
+				{SlotNo: 45, HeaderHash: "f'"},
+			},
+		},
+		rollback,
+	)
+	assert.Len(t, notified, 1)
+}
+
+func Test_ChainTrackerPollInterval(t *testing.T) {
+	client := kugo.New(kugo.WithPollInterval(time.Millisecond))
+	tracker := kugo.NewChainTracker(client, 0)
+	assert.Equal(t, time.Millisecond, kugo.ChainTrackerInterval(tracker))
+
+	tracker = kugo.NewChainTracker(client, 0, kugo.PollInterval(time.Minute))
+	assert.Equal(t, time.Minute, kugo.ChainTrackerInterval(tracker))
+}
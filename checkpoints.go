@@ -28,7 +28,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"time"
@@ -92,13 +91,9 @@ func (c *Client) Checkpoints(
 		return nil, fmt.Errorf("failed to build request: %w", err)
 	}
 
-	req.Close = true
 	req = req.WithContext(ctx)
 
-	client := &http.Client{
-		Timeout: c.options.timeout,
-	}
-	resp, err := client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve checkpoint by slot: %w", err)
 	}
@@ -106,17 +101,9 @@ func (c *Client) Checkpoints(
 		return nil, errors.New("failed with a nil response")
 	}
 	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+	body, err := readResponse(resp)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(
-			"got unexpected response: %v: %v",
-			resp.StatusCode,
-			string(body),
-		)
+		return nil, err
 	}
 
 	if o.singular {
@@ -156,6 +143,9 @@ func Latest() CheckpointsFilter {
 	return CheckpointsFilter{
 		before: nil,
 		after: func(points []Point) []Point {
+			if len(points) == 0 {
+				return points
+			}
 			return []Point{points[0]}
 		},
 	}
@@ -23,11 +23,23 @@
 
 package kugo
 
-import "github.com/SundaeSwap-finance/ogmigo/v6"
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/SundaeSwap-finance/ogmigo/v6"
+	"golang.org/x/sync/singleflight"
+)
 
 type Client struct {
-	logger  ogmigo.Logger
-	options Options
+	logger      ogmigo.Logger
+	options     Options
+	httpClient  *http.Client
+	datumCache  Cache
+	scriptCache Cache
+	flights     singleflight.Group
 }
 
 // New returns a new Client
@@ -35,8 +47,71 @@ func New(opts ...Option) *Client {
 	options := buildOptions(opts...)
 	logger := options.logger.With(ogmigo.KV("service", "kugo"))
 
-	return &Client{
-		logger:  logger,
-		options: options,
+	c := &Client{
+		logger:     logger,
+		options:    options,
+		httpClient: options.httpClient,
+	}
+	if options.datumCache != nil {
+		c.datumCache = NewLRUCache(*options.datumCache)
+	}
+	if options.scriptCache != nil {
+		c.scriptCache = NewLRUCache(*options.scriptCache)
+	}
+	return c
+}
+
+// do sends the request, retrying idempotent requests according to the
+// client's retry policy, and records the response metadata for callers using
+// RecordResponseInfo
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	resp, err := c.send(req)
+	if resp != nil {
+		recordResponseInfo(req.Context(), resp)
+	}
+	return resp, err
+}
+
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	policy := c.options.retry
+	if policy == nil ||
+		(req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		return c.httpClient.Do(req)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.httpClient.Do(req)
+		if attempt >= policy.MaxAttempts || !isRetryable(req, resp, err) {
+			return resp, err
+		}
+
+		delay, ok := retryAfter(resp, policy.MaxBackoff)
+		if !ok {
+			delay = policy.backoff(attempt)
+		}
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			// Drain the body so the connection can be reused
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		c.logger.Info(
+			"retrying request",
+			ogmigo.KV("url", req.URL.String()),
+			ogmigo.KV("attempt", fmt.Sprintf("%v", attempt)),
+			ogmigo.KV("delay", delay.String()),
+			ogmigo.KV("reason", reason),
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
 	}
 }
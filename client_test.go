@@ -0,0 +1,92 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/SundaeSwap-finance/kugo"
+	"github.com/SundaeSwap-finance/kugo/kugotest"
+	"github.com/tj/assert"
+)
+
+type countingTransport struct {
+	requests atomic.Int32
+	next     http.RoundTripper
+}
+
+func (t *countingTransport) RoundTrip(
+	req *http.Request,
+) (*http.Response, error) {
+	t.requests.Add(1)
+	return t.next.RoundTrip(req)
+}
+
+func Test_ConnectionReuse(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewUnstartedServer(
+		kugotest.New().Handler(),
+	)
+	var conns atomic.Int32
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	c := kugo.New(kugo.WithEndpoint(server.URL))
+	for i := 0; i < 5; i++ {
+		_, err := c.Patterns(context.Background())
+		assert.Nil(t, err)
+	}
+	assert.EqualValues(t, 1, conns.Load())
+}
+
+func Test_WithTransport(t *testing.T) {
+	t.Parallel()
+	server := kugotest.New().HTTP()
+	defer server.Close()
+
+	transport := &countingTransport{next: http.DefaultTransport}
+	c := kugo.New(kugo.WithEndpoint(server.URL), kugo.WithTransport(transport))
+	_, err := c.Patterns(context.Background())
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, transport.requests.Load())
+
+	transport = &countingTransport{next: http.DefaultTransport}
+	c = kugo.New(
+		kugo.WithEndpoint(server.URL),
+		kugo.WithHTTPClient(&http.Client{Transport: transport}),
+	)
+	_, err = c.Patterns(context.Background())
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, transport.requests.Load())
+}
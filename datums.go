@@ -25,20 +25,111 @@ package kugo
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/SundaeSwap-finance/kugo/plutusdata"
 	"github.com/SundaeSwap-finance/ogmigo/v6"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/sync/errgroup"
 )
 
+// Datum is hex encoded datum bytes, as returned by kupo
+type Datum string
+
+// Hash returns the blake2b-256 hash of the datum bytes, which is how datums
+// are referenced on chain
+func (d Datum) Hash() ([]byte, error) {
+	datumBytes, err := hex.DecodeString(string(d))
+	if err != nil {
+		return nil, fmt.Errorf("invalid datum hex: %w", err)
+	}
+	hash := blake2b.Sum256(datumBytes)
+	return hash[:], nil
+}
+
+// verify checks that the datum hashes to the expected hex encoded hash
+func (d Datum) verify(expected string) error {
+	hash, err := d.Hash()
+	if err != nil {
+		return err
+	}
+	actual := hex.EncodeToString(hash)
+	if !strings.EqualFold(actual, expected) {
+		return &IntegrityError{Kind: "datum", Expected: expected, Actual: actual}
+	}
+	return nil
+}
+
+// verifyMatch checks the datum kupo included in a match, inline or resolved
+// with ResolveHashes, against its hash, if the client verifies datums
+func (c *Client) verifyMatch(match Match) error {
+	if !c.options.verifyDatums || match.Datum == "" || match.DatumHash == "" {
+		return nil
+	}
+	if err := Datum(match.Datum).verify(match.DatumHash); err != nil {
+		return fmt.Errorf(
+			"datum of %v@%v: %w",
+			match.OutputIndex,
+			match.TransactionID,
+			err,
+		)
+	}
+	return nil
+}
+
 func (c *Client) Datum(
 	ctx context.Context,
 	datumHash string,
+) (string, error) {
+	if c.datumCache == nil {
+		return c.loadDatum(ctx, datumHash)
+	}
+	return cached(
+		ctx,
+		c,
+		c.datumCache,
+		"datum:"+datumHash,
+		func(ctx context.Context) (string, bool, error) {
+			datum, err := c.loadDatum(ctx, datumHash)
+			// kupo may learn of an unknown datum later, so don't cache that
+			return datum, datum != "", err
+		},
+	)
+}
+
+// loadDatum reads the datum from the disk cache, if any, or fetches it from
+// kupo and saves it there; only datums that match their hash are read from,
+// or written to, the disk cache
+func (c *Client) loadDatum(
+	ctx context.Context,
+	datumHash string,
+) (string, error) {
+	if disk := c.options.diskCache; disk != nil {
+		if value, ok := disk.get(diskDatum, datumHash); ok {
+			if datum := Datum(value); datum.verify(datumHash) == nil {
+				return string(datum), nil
+			}
+		}
+	}
+
+	datum, err := c.fetchDatum(ctx, datumHash)
+	if err == nil && datum != "" && Datum(datum).verify(datumHash) == nil {
+		c.storeOnDisk(diskDatum, datumHash, []byte(datum))
+	}
+	return datum, err
+}
+
+func (c *Client) fetchDatum(
+	ctx context.Context,
+	datumHash string,
 ) (datum string, err error) {
 	start := time.Now()
 	defer func() {
@@ -71,13 +162,9 @@ func (c *Client) Datum(
 		return "", fmt.Errorf("unable to build request: %w", err)
 	}
 
-	req.Close = true
 	req = req.WithContext(ctx)
 
-	client := &http.Client{
-		Timeout: c.options.timeout,
-	}
-	resp, err := client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return "", fmt.Errorf("unable to fetch datum: %w", err)
 	}
@@ -85,9 +172,9 @@ func (c *Client) Datum(
 		return "", errors.New("failed with a nil response")
 	}
 	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+	body, err := readResponse(resp)
 	if err != nil {
-		return "", fmt.Errorf("error reading response body: %w", err)
+		return "", err
 	}
 
 	type DatumResponse struct {
@@ -98,5 +185,76 @@ func (c *Client) Datum(
 	if err := json.Unmarshal(body, &response); err != nil {
 		return "", fmt.Errorf("unable to parse body %v: %w", string(body), err)
 	}
+	if c.options.verifyDatums && response.Datum != "" {
+		if err := Datum(response.Datum).verify(datumHash); err != nil {
+			return "", err
+		}
+	}
 	return response.Datum, nil
 }
+
+// ResolveDatums fills in the Datum of any match that only carries a datum
+// hash, fetching each distinct datum once with at most concurrency requests
+// in flight; if concurrency is 0, 8 requests are used
+func ResolveDatums(
+	ctx context.Context,
+	api API,
+	matches []Match,
+	concurrency int,
+) error {
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+
+	missing := map[string][]int{}
+	for i, match := range matches {
+		if match.DatumHash != "" && match.Datum == "" {
+			missing[match.DatumHash] = append(missing[match.DatumHash], i)
+		}
+	}
+
+	var mutex sync.Mutex
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(concurrency)
+	for hash, indexes := range missing {
+		group.Go(func() error {
+			datum, err := api.Datum(ctx, hash)
+			if err != nil {
+				return fmt.Errorf("unable to resolve datum %v: %w", hash, err)
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			for _, i := range indexes {
+				matches[i].Datum = datum
+			}
+			return nil
+		})
+	}
+	return group.Wait()
+}
+
+// DatumInto fetches the datum with the given hash and unmarshals it into v;
+// see plutusdata.Unmarshal for how Go types map to Plutus data
+func (c *Client) DatumInto(ctx context.Context, datumHash string, v any) error {
+	return DatumInto(ctx, c, datumHash, v)
+}
+
+// DatumInto is like Client.DatumInto, for any API
+func DatumInto(
+	ctx context.Context,
+	api API,
+	datumHash string,
+	v any,
+) error {
+	datum, err := api.Datum(ctx, datumHash)
+	if err != nil {
+		return err
+	}
+	if datum == "" {
+		return fmt.Errorf("datum %v: %w", datumHash, ErrNotFound)
+	}
+	if err := plutusdata.UnmarshalHex(datum, v); err != nil {
+		return fmt.Errorf("unable to decode datum %v: %w", datumHash, err)
+	}
+	return nil
+}
@@ -21,12 +21,18 @@
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
 // SOFTWARE.
 
-package kugo
+package kugo_test
 
 import (
 	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"net/http"
 	"testing"
 
+	"github.com/SundaeSwap-finance/kugo"
+	"github.com/SundaeSwap-finance/kugo/kugotest"
 	"github.com/tj/assert"
 )
 
@@ -36,14 +42,14 @@ func TestClient_Datum(t *testing.T) {
 		func(t *testing.T) {
 			t.Parallel()
 
-			server := NewMockServer().AddDatum(
+			server := kugotest.New().AddDatum(
 				"34215ad90b1ade84f5b4fe3c0a16cb3afeae468210535e0305efd93931f35059",
 				"d87980",
 			).
 				HTTP()
 			defer server.Close()
 
-			client := New(WithEndpoint(server.URL))
+			client := kugo.New(kugo.WithEndpoint(server.URL))
 			datumResponse, err := client.Datum(
 				context.Background(),
 				"34215ad90b1ade84f5b4fe3c0a16cb3afeae468210535e0305efd93931f35059",
@@ -59,10 +65,10 @@ func TestClient_Datum(t *testing.T) {
 		func(t *testing.T) {
 			t.Parallel()
 
-			server := NewMockServer().HTTP()
+			server := kugotest.New().HTTP()
 			defer server.Close()
 
-			client := New(WithEndpoint(server.URL))
+			client := kugo.New(kugo.WithEndpoint(server.URL))
 			datumResponse, err := client.Datum(
 				context.Background(),
 				"34215ad90b1ade84f5b4fe3c0a16cb3afeae468210535e0305efd93931f35059",
@@ -76,3 +82,163 @@ func TestClient_Datum(t *testing.T) {
 		},
 	)
 }
+
+func TestClient_ResolveDatums(t *testing.T) {
+	t.Parallel()
+
+	server := kugotest.New().
+		AddDatum("aaaa", "d87980").
+		AddDatum("bbbb", "d87a80").
+		HTTP()
+	defer server.Close()
+
+	transport := &countingTransport{next: http.DefaultTransport}
+	client := kugo.New(
+		kugo.WithEndpoint(server.URL),
+		kugo.WithTransport(transport),
+	)
+	matches := []kugo.Match{
+		{DatumHash: "aaaa", DatumType: "hash"},
+		{DatumHash: "bbbb", DatumType: "hash"},
+		{DatumHash: "aaaa", DatumType: "hash"},
+		{DatumHash: "cccc", DatumType: "inline", Datum: "01"},
+		{},
+	}
+	err := kugo.ResolveDatums(context.Background(), client, matches, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "d87980", matches[0].Datum)
+	assert.Equal(t, "d87a80", matches[1].Datum)
+	assert.Equal(t, "d87980", matches[2].Datum)
+	assert.Equal(t, "01", matches[3].Datum)
+	assert.Equal(t, "", matches[4].Datum)
+	assert.EqualValues(t, 2, transport.requests.Load())
+}
+
+func TestClient_DatumInto(t *testing.T) {
+	t.Parallel()
+
+	type Asset struct {
+		_      struct{} `plutus:"constr=0"`
+		Policy string
+		Amount *big.Int
+	}
+
+	server := kugotest.New().
+		AddDatum("aaaa", "d8799f42cafec249010000000000000000ff").
+		HTTP()
+	defer server.Close()
+	client := kugo.New(kugo.WithEndpoint(server.URL))
+
+	t.Run("decodes into struct", func(t *testing.T) {
+		var asset Asset
+		err := client.DatumInto(context.Background(), "aaaa", &asset)
+		assert.Nil(t, err)
+		assert.Equal(t, "cafe", asset.Policy)
+		assert.Equal(t, "18446744073709551616", asset.Amount.String())
+	})
+
+	t.Run("unknown datum", func(t *testing.T) {
+		var asset Asset
+		err := client.DatumInto(context.Background(), "bbbb", &asset)
+		assert.True(t, errors.Is(err, kugo.ErrNotFound))
+	})
+
+	t.Run("shape mismatch", func(t *testing.T) {
+		var n int
+		err := client.DatumInto(context.Background(), "aaaa", &n)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestDatum_Hash(t *testing.T) {
+	hash, err := kugo.Datum("d87980").Hash()
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		"923918e403bf43c34b4ef6b48eb2ee04babed17320d8d1b9ff9ad086e86f44ec",
+		hex.EncodeToString(hash),
+	)
+
+	_, err = kugo.Datum("d8798").Hash()
+	assert.NotNil(t, err)
+	_, err = kugo.Datum("zz").Hash()
+	assert.NotNil(t, err)
+}
+
+func TestClient_DatumVerification(t *testing.T) {
+	t.Parallel()
+
+	const unitHash = "923918e403bf43c34b4ef6b48eb2ee04babed17320d8d1b9ff9ad086e86f44ec"
+	const forgedHash = "34215ad90b1ade84f5b4fe3c0a16cb3afeae468210535e0305efd93931f35059"
+	server := kugotest.New().
+		AddDatums("d87980").
+		AddDatum(forgedHash, "d87980").
+		HTTP()
+	defer server.Close()
+
+	client := kugo.New(
+		kugo.WithEndpoint(server.URL),
+		kugo.WithDatumVerification(),
+	)
+	datum, err := client.Datum(context.Background(), unitHash)
+	assert.Nil(t, err)
+	assert.Equal(t, "d87980", datum)
+
+	_, err = client.Datum(context.Background(), forgedHash)
+	assert.True(t, errors.Is(err, kugo.ErrIntegrity))
+	var integrityErr *kugo.IntegrityError
+	assert.True(t, errors.As(err, &integrityErr))
+	assert.Equal(t, forgedHash, integrityErr.Expected)
+	assert.Equal(t, unitHash, integrityErr.Actual)
+
+	// Without verification, the forged datum is returned as is
+	datum, err = kugo.New(kugo.WithEndpoint(server.URL)).
+		Datum(context.Background(), forgedHash)
+	assert.Nil(t, err)
+	assert.Equal(t, "d87980", datum)
+}
+
+func TestClient_MatchesDatumVerification(t *testing.T) {
+	t.Parallel()
+
+	const unitHash = "923918e403bf43c34b4ef6b48eb2ee04babed17320d8d1b9ff9ad086e86f44ec"
+	const forgedHash = "34215ad90b1ade84f5b4fe3c0a16cb3afeae468210535e0305efd93931f35059"
+	server := kugotest.New().
+		AddMatches(
+			kugo.Match{
+				TransactionID: "tx1",
+				DatumHash:     unitHash,
+				Datum:         "d87980",
+			},
+			kugo.Match{
+				TransactionID: "tx2",
+				DatumHash:     forgedHash,
+				Datum:         "d87980",
+			},
+		).
+		HTTP()
+	defer server.Close()
+	ctx := context.Background()
+
+	client := kugo.New(
+		kugo.WithEndpoint(server.URL),
+		kugo.WithDatumVerification(),
+	)
+	_, err := client.Matches(ctx, kugo.Pattern("*"), kugo.ResolveHashes())
+	assert.True(t, errors.Is(err, kugo.ErrIntegrity))
+	assert.Contains(t, err.Error(), "tx2")
+
+	var streamErr error
+	for _, err := range client.MatchesStream(ctx, kugo.Pattern("*"), kugo.ResolveHashes()) {
+		if err != nil {
+			streamErr = err
+		}
+	}
+	assert.True(t, errors.Is(streamErr, kugo.ErrIntegrity))
+
+	// Without verification, the forged datum is returned as is
+	matches, err := kugo.New(kugo.WithEndpoint(server.URL)).
+		Matches(ctx, kugo.Pattern("*"), kugo.ResolveHashes())
+	assert.Nil(t, err)
+	assert.Len(t, matches, 2)
+}
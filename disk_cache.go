@@ -0,0 +1,313 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/SundaeSwap-finance/ogmigo/v6"
+)
+
+// DefaultStabilityWindow is the number of slots after which mainnet blocks
+// can no longer be rolled back (3k/f); the disk cache only keeps metadata
+// of slots at least this far behind kupo's most recent checkpoint
+const DefaultStabilityWindow = 129600
+
+const (
+	diskCacheFile  = "kugo.cache"
+	diskCacheMagic = "kugo-cache/1\n"
+
+	// a record is a header of crc32, kind, key length and value length,
+	// followed by the key and value; the crc covers everything after it
+	diskHeaderSize = 4 + 1 + 2 + 4
+)
+
+type diskKind byte
+
+const (
+	diskDatum diskKind = iota + 1
+	diskScript
+	diskMetadata
+)
+
+type diskKey struct {
+	kind diskKind
+	key  string
+}
+
+type diskRecord struct {
+	offset int64
+	size   int64
+}
+
+// DiskCache is a file backed store for values that never change once kupo
+// has indexed them: datums and scripts, by hash, and the metadata of
+// settled slots. It lets a client restarted often, such as a batch job,
+// skip warming back up against kupo; share it between clients with
+// WithDiskCache.
+//
+// Values are appended to a single log in a directory, and indexed in
+// memory when the cache is opened. The directory is locked while the cache
+// is open, so only one process may use it at a time. A torn or corrupted
+// tail, e.g. after a crash, is discarded.
+type DiskCache struct {
+	// StabilityWindow is how many slots behind kupo's most recent
+	// checkpoint metadata must be before it is cached; defaults to
+	// DefaultStabilityWindow, which is also safe for the testnets
+	StabilityWindow uint64
+
+	mutex  sync.RWMutex
+	file   *os.File
+	unlock func() error
+	size   int64
+	index  map[diskKey]diskRecord
+}
+
+// OpenDiskCache opens the cache in dir, creating it if need be
+func OpenDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("unable to create cache directory %v: %w", dir, err)
+	}
+	path := filepath.Join(dir, diskCacheFile)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open cache %v: %w", path, err)
+	}
+	unlock, err := lockDiskCache(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("unable to lock cache %v: %w", path, err)
+	}
+
+	cache := &DiskCache{
+		StabilityWindow: DefaultStabilityWindow,
+		file:            file,
+		unlock:          unlock,
+		index:           map[diskKey]diskRecord{},
+	}
+	if err := cache.load(); err != nil {
+		unlock()
+		file.Close()
+		return nil, fmt.Errorf("unable to load cache %v: %w", path, err)
+	}
+	return cache, nil
+}
+
+// load indexes the records in the log, truncating it after the last intact
+// record
+func (d *DiskCache) load() error {
+	info, err := d.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		if _, err := d.file.WriteAt([]byte(diskCacheMagic), 0); err != nil {
+			return err
+		}
+		d.size = int64(len(diskCacheMagic))
+		return nil
+	}
+
+	reader := bufio.NewReaderSize(io.NewSectionReader(d.file, 0, info.Size()), 1<<16)
+	magic := make([]byte, len(diskCacheMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil || string(magic) != diskCacheMagic {
+		return errors.New("not a kugo cache")
+	}
+
+	offset := int64(len(magic))
+	for {
+		kind, key, _, size, err := readDiskRecord(
+			reader,
+			info.Size()-offset,
+		)
+		if err != nil {
+			break
+		}
+		d.index[diskKey{kind: kind, key: key}] = diskRecord{offset: offset, size: size}
+		offset += size
+	}
+	if offset < info.Size() {
+		if err := d.file.Truncate(offset); err != nil {
+			return err
+		}
+	}
+	d.size = offset
+	return nil
+}
+
+// readDiskRecord reads and checks the record at the start of r, which has
+// remaining bytes left; the lengths in the header aren't checked by the CRC
+// until the body is read, so a record claiming more than remaining is torn
+// rather than allocated
+func readDiskRecord(
+	r io.Reader,
+	remaining int64,
+) (kind diskKind, key string, value []byte, size int64, err error) {
+	var header [diskHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, "", nil, 0, err
+	}
+	keyLen := int(binary.BigEndian.Uint16(header[5:]))
+	valueLen := int(binary.BigEndian.Uint32(header[7:]))
+	if int64(diskHeaderSize+keyLen+valueLen) > remaining {
+		return 0, "", nil, 0, io.ErrUnexpectedEOF
+	}
+	body := make([]byte, keyLen+valueLen)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, "", nil, 0, err
+	}
+
+	checksum := crc32.NewIEEE()
+	checksum.Write(header[4:])
+	checksum.Write(body)
+	if checksum.Sum32() != binary.BigEndian.Uint32(header[:4]) {
+		return 0, "", nil, 0, errors.New("checksum mismatch")
+	}
+	size = int64(diskHeaderSize + len(body))
+	return diskKind(header[4]), string(body[:keyLen]), body[keyLen:], size, nil
+}
+
+func (d *DiskCache) get(kind diskKind, key string) ([]byte, bool) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if d.file == nil {
+		return nil, false
+	}
+	record, ok := d.index[diskKey{kind: kind, key: strings.ToLower(key)}]
+	if !ok {
+		return nil, false
+	}
+	_, _, value, _, err := readDiskRecord(
+		io.NewSectionReader(d.file, record.offset, record.size),
+		record.size,
+	)
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+func (d *DiskCache) put(kind diskKind, key string, value []byte) error {
+	key = strings.ToLower(key)
+	if len(key) > math.MaxUint16 {
+		return fmt.Errorf("cache key too long: %v bytes", len(key))
+	}
+	if uint64(len(value)) > math.MaxUint32 {
+		return fmt.Errorf("cache value too long: %v bytes", len(value))
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.file == nil {
+		return nil
+	}
+	if _, ok := d.index[diskKey{kind: kind, key: key}]; ok {
+		return nil
+	}
+
+	record := make([]byte, diskHeaderSize, diskHeaderSize+len(key)+len(value))
+	record[4] = byte(kind)
+	binary.BigEndian.PutUint16(record[5:], uint16(len(key)))
+	binary.BigEndian.PutUint32(record[7:], uint32(len(value)))
+	record = append(record, key...)
+	record = append(record, value...)
+	binary.BigEndian.PutUint32(record, crc32.ChecksumIEEE(record[4:]))
+
+	if _, err := d.file.WriteAt(record, d.size); err != nil {
+		// Drop whatever part of the record made it, so the log stays intact
+		_ = d.file.Truncate(d.size)
+		return fmt.Errorf("unable to write to cache: %w", err)
+	}
+	d.index[diskKey{kind: kind, key: key}] = diskRecord{offset: d.size, size: int64(len(record))}
+	d.size += int64(len(record))
+	return nil
+}
+
+// isStable reports whether slot is far enough behind the checkpoint that it
+// can no longer be rolled back
+func (d *DiskCache) isStable(slot, checkpoint uint64) bool {
+	return slot+d.StabilityWindow <= checkpoint
+}
+
+// Len returns the number of values in the cache
+func (d *DiskCache) Len() int {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return len(d.index)
+}
+
+// Sync flushes the cache to stable storage
+func (d *DiskCache) Sync() error {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if d.file == nil {
+		return os.ErrClosed
+	}
+	return d.file.Sync()
+}
+
+// Close flushes and closes the cache; clients using it then go straight to
+// kupo
+func (d *DiskCache) Close() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.file == nil {
+		return nil
+	}
+	err := d.file.Sync()
+	if unlockErr := d.unlock(); err == nil {
+		err = unlockErr
+	}
+	if closeErr := d.file.Close(); err == nil {
+		err = closeErr
+	}
+	d.file = nil
+	return err
+}
+
+// storeOnDisk saves a value in the client's disk cache, if any; the cache is
+// an optimization, so failures are logged rather than returned
+func (c *Client) storeOnDisk(kind diskKind, key string, value []byte) {
+	if c.options.diskCache == nil {
+		return
+	}
+	if err := c.options.diskCache.put(kind, key, value); err != nil {
+		c.logger.Info("unable to write to disk cache", ogmigo.KV("err", err.Error()))
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo_test
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/SundaeSwap-finance/kugo"
+	"github.com/SundaeSwap-finance/kugo/kugotest"
+	"github.com/tj/assert"
+)
+
+func TestDiskCache_SurvivesRestart(t *testing.T) {
+	t.Parallel()
+
+	script := kugo.Script{
+		Language: kugo.ScriptLanguagePlutusV1,
+		Script:   "4d01000033222220051200120011",
+	}
+	scriptHash := "67f33146617a5e61936081db3b2117cbf59bd2123748f58ac9678656"
+	datumHash := "923918e403bf43c34b4ef6b48eb2ee04babed17320d8d1b9ff9ad086e86f44ec"
+	settled := kugo.Metadatum{
+		Hash:   "aa",
+		Raw:    "a10102",
+		Schema: json.RawMessage(`{"1":{"int":2}}`),
+	}
+	recent := kugo.Metadatum{
+		Hash:   "bb",
+		Raw:    "a10103",
+		Schema: json.RawMessage(`{"1":{"int":3}}`),
+	}
+	server := kugotest.New().
+		RollForward(kugo.Point{SlotNo: 200000, HeaderHash: "aa"}).
+		AddDatums("d87980").
+		AddScripts(script).
+		AddMetadata(1000, "tx1", settled).
+		AddMetadata(200000, "tx2", recent).
+		HTTP()
+	defer server.Close()
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	fetchAll := func(cache *kugo.DiskCache, transport http.RoundTripper) {
+		client := kugo.New(
+			kugo.WithEndpoint(server.URL),
+			kugo.WithTransport(transport),
+			kugo.WithDiskCache(cache),
+		)
+
+		datum, err := client.Datum(ctx, datumHash)
+		assert.Nil(t, err)
+		assert.Equal(t, "d87980", datum)
+
+		got, err := client.Script(ctx, scriptHash)
+		assert.Nil(t, err)
+		assert.Equal(t, script, *got)
+
+		metadata, err := client.Metadata(ctx, 1000, "")
+		assert.Nil(t, err)
+		assert.Equal(t, []kugo.Metadatum{settled}, metadata)
+
+		metadata, err = client.Metadata(ctx, 200000, "")
+		assert.Nil(t, err)
+		assert.Equal(t, []kugo.Metadatum{recent}, metadata)
+
+		// Unknown values aren't cached
+		datum, err = client.Datum(ctx, "bbbb")
+		assert.Nil(t, err)
+		assert.Equal(t, "", datum)
+	}
+
+	cache, err := kugo.OpenDiskCache(dir)
+	assert.Nil(t, err)
+	first := &countingTransport{next: http.DefaultTransport}
+	fetchAll(cache, first)
+	assert.EqualValues(t, 5, first.requests.Load())
+	assert.Equal(t, 3, cache.Len())
+	assert.Nil(t, cache.Close())
+
+	cache, err = kugo.OpenDiskCache(dir)
+	assert.Nil(t, err)
+	defer cache.Close()
+	second := &countingTransport{next: http.DefaultTransport}
+	fetchAll(cache, second)
+	// Only the unsettled metadata and the unknown datum go to kupo
+	assert.EqualValues(t, 2, second.requests.Load())
+}
+
+func TestDiskCache_Recovery(t *testing.T) {
+	t.Run("torn tail is discarded", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		cache, err := kugo.OpenDiskCache(dir)
+		assert.Nil(t, err)
+		assert.Nil(
+			t,
+			kugo.DiskCachePut(cache, kugo.DiskDatum, "aaaa", []byte("d87980")),
+		)
+		assert.Nil(
+			t,
+			kugo.DiskCachePut(cache, kugo.DiskDatum, "BBBB", []byte("d87a80")),
+		)
+		assert.Nil(t, cache.Close())
+
+		// Simulate a crash part way through writing a record
+		path := filepath.Join(dir, kugo.DiskCacheFile)
+		info, err := os.Stat(path)
+		assert.Nil(t, err)
+		assert.Nil(t, os.Truncate(path, info.Size()-2))
+
+		cache, err = kugo.OpenDiskCache(dir)
+		assert.Nil(t, err)
+		defer cache.Close()
+		assert.Equal(t, 1, cache.Len())
+		value, ok := kugo.DiskCacheGet(cache, kugo.DiskDatum, "AAAA")
+		assert.True(t, ok)
+		assert.Equal(t, "d87980", string(value))
+		_, ok = kugo.DiskCacheGet(cache, kugo.DiskDatum, "bbbb")
+		assert.False(t, ok)
+
+		assert.Nil(
+			t,
+			kugo.DiskCachePut(cache, kugo.DiskDatum, "bbbb", []byte("d87a80")),
+		)
+		value, ok = kugo.DiskCacheGet(cache, kugo.DiskDatum, "bbbb")
+		assert.True(t, ok)
+		assert.Equal(t, "d87a80", string(value))
+	})
+
+	t.Run("corrupted record is discarded", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		cache, err := kugo.OpenDiskCache(dir)
+		assert.Nil(t, err)
+		assert.Nil(
+			t,
+			kugo.DiskCachePut(
+				cache,
+				kugo.DiskScript,
+				"aaaa",
+				[]byte(`{"Language":"native"}`),
+			),
+		)
+		assert.Nil(t, cache.Close())
+
+		path := filepath.Join(dir, kugo.DiskCacheFile)
+		data, err := os.ReadFile(path)
+		assert.Nil(t, err)
+		data[len(data)-1] ^= 0xff
+		assert.Nil(t, os.WriteFile(path, data, 0o644))
+
+		cache, err = kugo.OpenDiskCache(dir)
+		assert.Nil(t, err)
+		defer cache.Close()
+		assert.Equal(t, 0, cache.Len())
+	})
+
+	t.Run(
+		"corrupted length is discarded before allocating",
+		func(t *testing.T) {
+			dir := t.TempDir()
+			cache, err := kugo.OpenDiskCache(dir)
+			assert.Nil(t, err)
+			assert.Nil(
+				t,
+				kugo.DiskCachePut(
+					cache,
+					kugo.DiskDatum,
+					"aaaa",
+					[]byte("d87980"),
+				),
+			)
+			assert.Nil(
+				t,
+				kugo.DiskCachePut(
+					cache,
+					kugo.DiskDatum,
+					"bbbb",
+					[]byte("d87a80"),
+				),
+			)
+			assert.Nil(t, cache.Close())
+
+			// Flip the value length of the last record to nearly 4 GiB
+			path := filepath.Join(dir, kugo.DiskCacheFile)
+			data, err := os.ReadFile(path)
+			assert.Nil(t, err)
+			last := len(
+				data,
+			) - (kugo.DiskHeaderSize + len("bbbb") + len("d87a80"))
+			binary.BigEndian.PutUint32(data[last+7:], math.MaxUint32)
+			assert.Nil(t, os.WriteFile(path, data, 0o644))
+
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			cache, err = kugo.OpenDiskCache(dir)
+			runtime.ReadMemStats(&after)
+			assert.Nil(t, err)
+			defer cache.Close()
+			assert.Equal(t, 1, cache.Len())
+			assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1<<20))
+		},
+	)
+
+	t.Run("other files are rejected", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		path := filepath.Join(dir, kugo.DiskCacheFile)
+		assert.Nil(t, os.WriteFile(path, []byte("something else"), 0o644))
+
+		_, err := kugo.OpenDiskCache(dir)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "not a kugo cache")
+	})
+}
+
+func TestDiskCache_Locked(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cache, err := kugo.OpenDiskCache(dir)
+	assert.Nil(t, err)
+
+	_, err = kugo.OpenDiskCache(dir)
+	assert.True(t, errors.Is(err, kugo.ErrDiskCacheLocked))
+
+	// Closing releases the lock
+	assert.Nil(t, cache.Close())
+	cache, err = kugo.OpenDiskCache(dir)
+	assert.Nil(t, err)
+	assert.Nil(t, cache.Close())
+}
+
+func TestDiskCache_VerifiesHashes(t *testing.T) {
+	t.Parallel()
+
+	script := kugo.Script{
+		Language: kugo.ScriptLanguagePlutusV1,
+		Script:   "4d01000033222220051200120011",
+	}
+	scriptHash := "67f33146617a5e61936081db3b2117cbf59bd2123748f58ac9678656"
+	datumHash := "923918e403bf43c34b4ef6b48eb2ee04babed17320d8d1b9ff9ad086e86f44ec"
+	server := kugotest.New().
+		AddDatums("d87980").
+		AddScripts(script).
+		HTTP()
+	defer server.Close()
+
+	cache, err := kugo.OpenDiskCache(t.TempDir())
+	assert.Nil(t, err)
+	defer cache.Close()
+	// Values that don't match their hash, as if written by a buggy version
+	assert.Nil(
+		t,
+		kugo.DiskCachePut(cache, kugo.DiskDatum, datumHash, []byte("d87a80")),
+	)
+	assert.Nil(t, kugo.DiskCachePut(
+		cache,
+		kugo.DiskScript,
+		scriptHash,
+		[]byte(
+			`{"Language":"plutus:v2","Script":"4d01000033222220051200120011"}`,
+		),
+	))
+
+	transport := &countingTransport{next: http.DefaultTransport}
+	client := kugo.New(
+		kugo.WithEndpoint(server.URL),
+		kugo.WithTransport(transport),
+		kugo.WithDiskCache(cache),
+	)
+	ctx := context.Background()
+
+	datum, err := client.Datum(ctx, datumHash)
+	assert.Nil(t, err)
+	assert.Equal(t, "d87980", datum)
+	got, err := client.Script(ctx, scriptHash)
+	assert.Nil(t, err)
+	assert.Equal(t, script, *got)
+	assert.EqualValues(t, 2, transport.requests.Load())
+}
@@ -0,0 +1,163 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrNotFound is matched by any *Error for a 404 response
+	ErrNotFound = errors.New("not found")
+	// ErrPatternNotIndexed is matched when kupo rejects a query because the
+	// pattern isn't covered by the patterns it is configured to index
+	ErrPatternNotIndexed = errors.New("pattern not indexed")
+	// ErrServerBusy is matched when kupo is overloaded or rate limiting us
+	ErrServerBusy = errors.New("server busy")
+	// ErrIntegrity is matched by any *IntegrityError
+	ErrIntegrity = errors.New("integrity check failed")
+	// ErrDiskCacheLocked is returned by OpenDiskCache when another process,
+	// or another DiskCache, already has the directory open
+	ErrDiskCacheLocked = errors.New("disk cache is locked")
+)
+
+// notIndexedHints are the phrases kupo's hints use for a pattern outside
+// the ones it indexes; validation errors, which also mention the pattern,
+// are worded differently
+var notIndexedHints = []string{
+	"not included",
+	"not indexed",
+	"not configured",
+	"not part of",
+	"isn't part of",
+	"not one of",
+	"isn't one of",
+	"unknown pattern",
+}
+
+// Error is returned for any non-2xx response from kupo
+type Error struct {
+	StatusCode int
+	// Hint is kupo's human readable explanation of the failure, or the raw
+	// response body if kupo didn't send one
+	Hint string
+	URL  string
+}
+
+func (e *Error) Error() string {
+	if e.Hint == "" {
+		return fmt.Sprintf("kupo responded %v to %v", e.StatusCode, e.URL)
+	}
+	return fmt.Sprintf(
+		"kupo responded %v to %v: %v",
+		e.StatusCode,
+		e.URL,
+		e.Hint,
+	)
+}
+
+// Is allows the sentinel errors above to be used with errors.Is
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrPatternNotIndexed:
+		if e.StatusCode != http.StatusBadRequest &&
+			e.StatusCode != http.StatusNotFound {
+			return false
+		}
+		hint := strings.ToLower(e.Hint)
+		if !strings.Contains(hint, "pattern") ||
+			strings.Contains(hint, "invalid") {
+			return false
+		}
+		for _, phrase := range notIndexedHints {
+			if strings.Contains(hint, phrase) {
+				return true
+			}
+		}
+		return false
+	case ErrServerBusy:
+		return e.StatusCode == http.StatusServiceUnavailable ||
+			e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// IntegrityError is returned when content served by kupo doesn't hash to
+// the hash it was requested by
+type IntegrityError struct {
+	// Kind of content that failed verification, e.g. "datum"
+	Kind     string
+	Expected string
+	Actual   string
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf(
+		"%v integrity check failed: expected hash %v, got %v",
+		e.Kind,
+		e.Expected,
+		e.Actual,
+	)
+}
+
+// Is allows ErrIntegrity to be used with errors.Is
+func (e *IntegrityError) Is(target error) bool {
+	return target == ErrIntegrity
+}
+
+// readResponse reads the full response body, converting any non-2xx
+// response into an *Error
+func readResponse(resp *http.Response) ([]byte, error) {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return body, nil
+	}
+	return nil, newError(resp, body)
+}
+
+func newError(resp *http.Response, body []byte) *Error {
+	e := &Error{StatusCode: resp.StatusCode}
+	if resp.Request != nil && resp.Request.URL != nil {
+		e.URL = resp.Request.URL.String()
+	}
+	var hint struct {
+		Hint string `json:"hint"`
+	}
+	if err := json.Unmarshal(body, &hint); err == nil && hint.Hint != "" {
+		e.Hint = hint.Hint
+	} else {
+		e.Hint = strings.TrimSpace(string(body))
+	}
+	return e
+}
@@ -0,0 +1,150 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func Test_Errors(t *testing.T) {
+	t.Run(
+		"Not found",
+		func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					writeError(w, http.StatusNotFound, "Not found")
+				}),
+			)
+			defer server.Close()
+
+			c := New(WithEndpoint(server.URL))
+			_, err := c.Matches(context.Background(), Pattern("addr1"))
+			assert.True(t, errors.Is(err, ErrNotFound))
+			assert.False(t, errors.Is(err, ErrServerBusy))
+
+			var kerr *Error
+			assert.True(t, errors.As(err, &kerr))
+			assert.Equal(t, http.StatusNotFound, kerr.StatusCode)
+			assert.Equal(t, server.URL+"/v1/matches/addr1", kerr.URL)
+		},
+	)
+
+	t.Run(
+		"Server busy",
+		func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					writeError(
+						w,
+						http.StatusServiceUnavailable,
+						"too many connections",
+					)
+				}),
+			)
+			defer server.Close()
+
+			c := New(WithEndpoint(server.URL))
+			_, err := c.Datum(context.Background(), "abc")
+			assert.True(t, errors.Is(err, ErrServerBusy))
+			assert.False(t, errors.Is(err, ErrNotFound))
+			assert.Equal(
+				t,
+				"kupo responded 503 to "+server.URL+"/v1/datums/abc: too many connections",
+				err.Error(),
+			)
+		},
+	)
+
+	t.Run(
+		"Non-json body",
+		func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = w.Write([]byte("bad request\n"))
+				}),
+			)
+			defer server.Close()
+
+			c := New(WithEndpoint(server.URL))
+			_, err := c.Patterns(context.Background())
+			var kerr *Error
+			assert.True(t, errors.As(err, &kerr))
+			assert.Equal(t, "bad request", kerr.Hint)
+		},
+	)
+}
+
+func TestError_PatternNotIndexed(t *testing.T) {
+	testCases := map[string]struct {
+		StatusCode int
+		Hint       string
+		Want       bool
+	}{
+		"not included": {
+			StatusCode: http.StatusBadRequest,
+			Hint:       "The pattern addr1 is not included in the server's configured patterns.",
+			Want:       true,
+		},
+		"unknown pattern": {
+			StatusCode: http.StatusNotFound,
+			Hint:       "Unknown pattern.",
+			Want:       true,
+		},
+		"invalid pattern": {
+			StatusCode: http.StatusBadRequest,
+			Hint:       "Invalid pattern: cannot parse credential",
+		},
+		"note about patterns": {
+			StatusCode: http.StatusBadRequest,
+			Hint:       "Note: patterns must be url-encoded",
+		},
+		"not a pattern error": {
+			StatusCode: http.StatusBadRequest,
+			Hint:       "Invalid slot number.",
+		},
+		"server error": {
+			StatusCode: http.StatusInternalServerError,
+			Hint:       "The pattern addr1 is not indexed",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := &Error{StatusCode: tc.StatusCode, Hint: tc.Hint}
+			assert.Equal(t, tc.Want, errors.Is(err, ErrPatternNotIndexed))
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo
+
+import (
+	"net/url"
+	"time"
+)
+
+// Internals for the tests in package kugo_test, which run against kugotest
+// and so can't live in this package
+
+const (
+	DiskCacheFile  = diskCacheFile
+	DiskHeaderSize = diskHeaderSize
+	DiskDatum      = diskDatum
+	DiskScript     = diskScript
+)
+
+var (
+	CacheLimitsWithDefaults = CacheLimits.withDefaults
+	DiskCacheGet            = (*DiskCache).get
+	DiskCachePut            = (*DiskCache).put
+)
+
+// ChainTrackerInterval returns how often tracker polls
+func ChainTrackerInterval(tracker *ChainTracker) time.Duration {
+	return tracker.options.interval
+}
+
+// ApplyMatchesFilters sets the path and query of a matches request to u
+// from filters
+func ApplyMatchesFilters(u *url.URL, filters ...MatchesFilter) {
+	opts := matchesOptions{}
+	for _, f := range filters {
+		f(&opts)
+	}
+	opts.apply(u)
+}
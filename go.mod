@@ -7,6 +7,7 @@ require (
 	github.com/tj/assert v0.0.3
 	github.com/urfave/cli/v2 v2.27.7
 	golang.org/x/crypto v0.54.0
+	golang.org/x/sync v0.10.0
 )
 
 require (
@@ -24,7 +25,6 @@ require (
 	github.com/stretchr/testify v1.8.1 // indirect
 	github.com/x448/float16 v0.8.4 // indirect
 	github.com/xrash/smetrics v0.0.0-20240521201337-686a1a2994c1 // indirect
-	golang.org/x/sync v0.10.0 // indirect
 	golang.org/x/sys v0.47.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
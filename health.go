@@ -0,0 +1,245 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SundaeSwap-finance/ogmigo/v6"
+)
+
+// healthPath is kupo's health endpoint
+const healthPath = "/health"
+
+const (
+	ConnectionStatusConnected    = "connected"
+	ConnectionStatusDisconnected = "disconnected"
+)
+
+type HealthConfiguration struct {
+	// Indexes is either "deferred" or "installed"
+	Indexes string `json:"indexes,omitempty"`
+}
+
+// Health reports kupo's connection to the node and how far it has synced
+type Health struct {
+	ConnectionStatus       string              `json:"connection_status,omitempty"`
+	MostRecentCheckpoint   uint64              `json:"most_recent_checkpoint,omitempty"`
+	MostRecentNodeTip      uint64              `json:"most_recent_node_tip,omitempty"`
+	SecondsSinceLastBlock  uint64              `json:"seconds_since_last_block,omitempty"`
+	NetworkSynchronization float64             `json:"network_synchronization,omitempty"`
+	Configuration          HealthConfiguration `json:"configuration,omitempty"`
+	Version                string              `json:"version,omitempty"`
+
+	// SyncLag is the number of slots kupo's most recent checkpoint trails
+	// the node's tip by
+	SyncLag uint64 `json:"-"`
+}
+
+func (h Health) Connected() bool {
+	return h.ConnectionStatus == ConnectionStatusConnected
+}
+
+// Synced reports whether kupo is connected, and within tolerance slots of
+// the node's tip
+func (h Health) Synced(tolerance uint64) bool {
+	return h.Connected() && h.MostRecentNodeTip != 0 && h.SyncLag <= tolerance
+}
+
+func (c *Client) Health(ctx context.Context) (health *Health, err error) {
+	start := time.Now()
+	defer func() {
+		errStr := ""
+		if err != nil {
+			errStr = err.Error()
+		}
+		c.options.logger.Debug(
+			"Health() finished",
+			ogmigo.KV(
+				"duration",
+				time.Since(start).Round(time.Millisecond).String(),
+			),
+			ogmigo.KV("err", errStr),
+		)
+	}()
+
+	url, err := url.Parse(c.options.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"unable to parse endpoint %v: %w",
+			c.options.endpoint,
+			err,
+		)
+	}
+	url.Path = healthPath
+
+	req, err := http.NewRequest(http.MethodGet, url.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req = req.WithContext(ctx)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch health: %w", err)
+	}
+	if resp == nil {
+		return nil, errors.New("failed with a nil response")
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+
+	// kupo answers 503 while disconnected from the node, but still reports
+	// its health in the body
+	if resp.StatusCode != http.StatusOK &&
+		resp.StatusCode != http.StatusServiceUnavailable {
+		return nil, newError(resp, body)
+	}
+
+	health = &Health{}
+	// Prefer the body over the Content-Type, which proxies may rewrite
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '{' {
+		err = json.Unmarshal(body, health)
+	} else {
+		err = parsePrometheusHealth(body, health)
+	}
+	if err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, newError(resp, body)
+		}
+		return nil, fmt.Errorf("unable to parse body %v: %w", string(body), err)
+	}
+	if health.MostRecentNodeTip > health.MostRecentCheckpoint {
+		health.SyncLag = health.MostRecentNodeTip - health.MostRecentCheckpoint
+	}
+	return health, nil
+}
+
+// parsePrometheusHealth reads the metrics kupo exposes in prometheus' text
+// format
+func parsePrometheusHealth(body []byte, health *Health) error {
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return fmt.Errorf("malformed metric: %v", line)
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return fmt.Errorf("malformed metric %v: %w", line, err)
+		}
+		switch fields[0] {
+		case "kupo_connection_status":
+			health.ConnectionStatus = ConnectionStatusDisconnected
+			if value == 1 {
+				health.ConnectionStatus = ConnectionStatusConnected
+			}
+		case "kupo_most_recent_checkpoint":
+			health.MostRecentCheckpoint = uint64(value)
+		case "kupo_most_recent_node_tip":
+			health.MostRecentNodeTip = uint64(value)
+		case "kupo_seconds_since_last_block":
+			health.SecondsSinceLastBlock = uint64(value)
+		case "kupo_network_synchronization":
+			health.NetworkSynchronization = value
+		case "kupo_configuration_indexes":
+			health.Configuration.Indexes = "deferred"
+			if value == 1 {
+				health.Configuration.Indexes = "installed"
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// WaitUntilSynced polls kupo's health, at the client's poll interval, until
+// it is connected and within tolerance slots of the node's tip. Errors while
+// polling (e.g. kupo still starting) are logged and retried until ctx is
+// done.
+func (c *Client) WaitUntilSynced(ctx context.Context, tolerance uint64) error {
+	return WaitUntilSynced(ctx, c, tolerance, c.pollOptions()...)
+}
+
+// WaitUntilSynced is like Client.WaitUntilSynced, for any API
+func WaitUntilSynced(
+	ctx context.Context,
+	api API,
+	tolerance uint64,
+	opts ...PollOption,
+) error {
+	options := buildPollOptions(opts...)
+	ticker := time.NewTicker(options.interval)
+	defer ticker.Stop()
+
+	var lastErr error
+	for {
+		health, err := api.Health(ctx)
+		switch {
+		case err != nil:
+			lastErr = err
+			options.logger.Info(
+				"waiting for kupo to become available",
+				ogmigo.KV("err", err.Error()),
+			)
+		case health.Synced(tolerance):
+			return nil
+		default:
+			lastErr = nil
+			options.logger.Info(
+				"waiting for kupo to sync",
+				ogmigo.KV("status", health.ConnectionStatus),
+				ogmigo.KV("lag", fmt.Sprintf("%v", health.SyncLag)),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return fmt.Errorf("%w: %w", ctx.Err(), lastErr)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
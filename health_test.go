@@ -0,0 +1,198 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+)
+
+func Test_Health(t *testing.T) {
+	t.Run(
+		"JSON",
+		func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					assert.Equal(t, "/health", r.URL.Path)
+					assert.Equal(t, "application/json", r.Header.Get("Accept"))
+					w.Header().Set("Content-Type", "application/json;charset=utf-8")
+					_, _ = w.Write([]byte(`{
+						"connection_status": "connected",
+						"most_recent_checkpoint": 1000,
+						"most_recent_node_tip": 1042,
+						"seconds_since_last_block": 3,
+						"network_synchronization": 0.99,
+						"configuration": {"indexes": "installed"},
+						"version": "v2.9.0"
+					}`))
+				}),
+			)
+			defer server.Close()
+
+			c := New(WithEndpoint(server.URL))
+			health, err := c.Health(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, &Health{
+				ConnectionStatus:       ConnectionStatusConnected,
+				MostRecentCheckpoint:   1000,
+				MostRecentNodeTip:      1042,
+				SecondsSinceLastBlock:  3,
+				NetworkSynchronization: 0.99,
+				Configuration:          HealthConfiguration{Indexes: "installed"},
+				Version:                "v2.9.0",
+				SyncLag:                42,
+			}, health)
+			assert.True(t, health.Synced(42))
+			assert.False(t, health.Synced(41))
+		},
+	)
+
+	t.Run(
+		"Prometheus",
+		func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Type", "text/plain;charset=utf-8")
+					w.WriteHeader(http.StatusServiceUnavailable)
+					_, _ = w.Write([]byte(
+						"# TYPE kupo_connection_status gauge\n" +
+							"kupo_connection_status  0.0\n" +
+							"# TYPE kupo_most_recent_checkpoint counter\n" +
+							"kupo_most_recent_checkpoint  1000\n" +
+							"# TYPE kupo_most_recent_node_tip counter\n" +
+							"kupo_most_recent_node_tip  1500\n",
+					))
+				}),
+			)
+			defer server.Close()
+
+			c := New(WithEndpoint(server.URL))
+			health, err := c.Health(context.Background())
+			assert.Nil(t, err)
+			assert.False(t, health.Connected())
+			assert.EqualValues(t, 500, health.SyncLag)
+		},
+	)
+}
+
+func Test_WaitUntilSynced(t *testing.T) {
+	t.Run(
+		"Waits for kupo to catch up",
+		func(t *testing.T) {
+			t.Parallel()
+
+			var checkpoint atomic.Int64
+			server := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if checkpoint.Load() == 0 {
+						checkpoint.Store(100)
+						w.WriteHeader(http.StatusBadGateway)
+						return
+					}
+					writeSuccess(w, Health{
+						ConnectionStatus:     ConnectionStatusConnected,
+						MostRecentCheckpoint: uint64(checkpoint.Add(300)),
+						MostRecentNodeTip:    1000,
+					})
+				}),
+			)
+			defer server.Close()
+
+			c := New(
+				WithEndpoint(server.URL),
+				WithPollInterval(time.Millisecond),
+			)
+			err := c.WaitUntilSynced(context.Background(), 100)
+			assert.Nil(t, err)
+			assert.EqualValues(t, 1000, checkpoint.Load())
+		},
+	)
+
+	t.Run(
+		"Polls a disconnected kupo without retrying",
+		func(t *testing.T) {
+			t.Parallel()
+
+			var requests atomic.Int64
+			server := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					requests.Add(1)
+					w.WriteHeader(http.StatusServiceUnavailable)
+					_, _ = w.Write([]byte(`{"connection_status":"disconnected"}`))
+				}),
+			)
+			defer server.Close()
+
+			c := New(
+				WithEndpoint(server.URL),
+				WithRetry(RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Minute}),
+				WithPollInterval(time.Millisecond),
+			)
+			health, err := c.Health(context.Background())
+			assert.Nil(t, err)
+			assert.False(t, health.Connected())
+			assert.EqualValues(t, 1, requests.Load())
+
+			ctx, cancel := context.WithTimeout(
+				context.Background(),
+				50*time.Millisecond,
+			)
+			defer cancel()
+			err = c.WaitUntilSynced(ctx, 100)
+			assert.True(t, errors.Is(err, context.DeadlineExceeded))
+			assert.True(t, requests.Load() > 2)
+		},
+	)
+
+	t.Run(
+		"Gives up when the context is done",
+		func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					writeSuccess(w, Health{ConnectionStatus: ConnectionStatusDisconnected})
+				}),
+			)
+			defer server.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			c := New(WithEndpoint(server.URL))
+			err := WaitUntilSynced(ctx, c, 100, PollInterval(time.Millisecond))
+			assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		},
+	)
+}
@@ -0,0 +1,200 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugotest
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/SundaeSwap-finance/kugo"
+)
+
+func itoa(i int) string {
+	return strconv.Itoa(i)
+}
+
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(code)
+	_, _ = w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, code int, hint string) {
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"hint": hint})
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	health := kugo.Health{
+		ConnectionStatus: kugo.ConnectionStatusDisconnected,
+		Configuration:    kugo.HealthConfiguration{Indexes: "installed"},
+		Version:          "kugotest",
+	}
+	if len(s.checkpoints) > 0 {
+		health.MostRecentCheckpoint = uint64(s.checkpoints[len(s.checkpoints)-1].SlotNo)
+	}
+	health.MostRecentNodeTip = max(s.nodeTip, health.MostRecentCheckpoint)
+	if health.MostRecentNodeTip > 0 {
+		health.NetworkSynchronization = float64(health.MostRecentCheckpoint) / float64(health.MostRecentNodeTip)
+	}
+
+	code := http.StatusServiceUnavailable
+	if s.connected {
+		health.ConnectionStatus = kugo.ConnectionStatusConnected
+		code = http.StatusOK
+	}
+	writeJSON(w, code, health)
+}
+
+// handleCheckpoints lists checkpoints, most recent first
+func (s *Server) handleCheckpoints(w http.ResponseWriter, r *http.Request) {
+	points := slices.Clone(s.checkpoints)
+	slices.Reverse(points)
+	if points == nil {
+		points = []kugo.Point{}
+	}
+	writeJSON(w, http.StatusOK, points)
+}
+
+// handleCheckpoint returns the checkpoint at slot, or the closest one before
+// it; with ?strict, only an exact match
+func (s *Server) handleCheckpoint(w http.ResponseWriter, r *http.Request) {
+	slot, err := strconv.Atoi(r.PathValue("slot"))
+	if err != nil || slot < 0 {
+		writeError(w, http.StatusBadRequest, "Invalid slot number.")
+		return
+	}
+	_, strict := r.URL.Query()["strict"]
+	for i := len(s.checkpoints) - 1; i >= 0; i-- {
+		point := s.checkpoints[i]
+		if point.SlotNo == slot || (!strict && point.SlotNo < slot) {
+			writeJSON(w, http.StatusOK, point)
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, nil)
+}
+
+func (s *Server) handlePatterns(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.patternList())
+}
+
+func (s *Server) patternList() []string {
+	if s.patterns == nil {
+		return []string{}
+	}
+	return slices.Clone(s.patterns)
+}
+
+// handlePatternIncluded lists the indexed patterns which include the given
+// pattern
+func (s *Server) handlePatternIncluded(w http.ResponseWriter, r *http.Request) {
+	query, err := kugo.ParsePattern(r.PathValue("pattern"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid pattern: "+err.Error())
+		return
+	}
+	included := []string{}
+	for _, pattern := range s.patterns {
+		if spec, err := kugo.ParsePattern(pattern); err == nil && includes(spec, query) {
+			included = append(included, pattern)
+		}
+	}
+	writeJSON(w, http.StatusOK, included)
+}
+
+func (s *Server) handleAddPattern(w http.ResponseWriter, r *http.Request) {
+	pattern := r.PathValue("pattern")
+	if _, err := kugo.ParsePattern(pattern); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid pattern: "+err.Error())
+		return
+	}
+	var body struct {
+		RollbackTo *struct {
+			SlotNo *int `json:"slot_no"`
+		} `json:"rollback_to"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil || body.RollbackTo == nil || body.RollbackTo.SlotNo == nil {
+		writeError(w, http.StatusBadRequest, "Missing or invalid 'rollback_to' in request body.")
+		return
+	}
+	if !slices.Contains(s.patterns, pattern) {
+		s.patterns = append(s.patterns, pattern)
+	}
+	writeJSON(w, http.StatusOK, s.patternList())
+}
+
+func (s *Server) handleRemovePattern(w http.ResponseWriter, r *http.Request) {
+	pattern := r.PathValue("pattern")
+	before := len(s.patterns)
+	s.patterns = slices.DeleteFunc(s.patterns, func(p string) bool {
+		return p == pattern
+	})
+	writeJSON(w, http.StatusOK, map[string]int{"deleted": before - len(s.patterns)})
+}
+
+func (s *Server) handleDatum(w http.ResponseWriter, r *http.Request) {
+	datum, ok := s.datums[r.PathValue("hash")]
+	if !ok {
+		writeJSON(w, http.StatusOK, nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"datum": datum})
+}
+
+func (s *Server) handleScript(w http.ResponseWriter, r *http.Request) {
+	script, ok := s.scripts[r.PathValue("hash")]
+	if !ok {
+		writeJSON(w, http.StatusOK, nil)
+		return
+	}
+	writeJSON(w, http.StatusOK, &script)
+}
+
+func (s *Server) handleMetadata(w http.ResponseWriter, r *http.Request) {
+	slot, err := strconv.Atoi(r.PathValue("slot"))
+	if err != nil || slot < 0 {
+		writeError(w, http.StatusBadRequest, "Invalid slot number.")
+		return
+	}
+	metadata := []kugo.Metadatum{}
+	if txID := r.URL.Query().Get("transaction_id"); txID != "" {
+		metadata = append(metadata, s.metadata[slot][txID]...)
+	} else {
+		for _, txID := range slices.Sorted(maps.Keys(s.metadata[slot])) {
+			metadata = append(metadata, s.metadata[slot][txID]...)
+		}
+	}
+	writeJSON(w, http.StatusOK, metadata)
+}
@@ -0,0 +1,310 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugotest
+
+import (
+	"cmp"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/SundaeSwap-finance/kugo"
+	"github.com/SundaeSwap-finance/kugo/address"
+)
+
+// matchesQuery holds the query string filters kupo supports on matches
+type matchesQuery struct {
+	spent         bool
+	unspent       bool
+	resolveHashes bool
+	oldestFirst   bool
+	createdBefore *int
+	createdAfter  *int
+	spentBefore   *int
+	spentAfter    *int
+	policyID      string
+	assetName     string
+	transactionID string
+	outputIndex   *int
+}
+
+func parseMatchesQuery(values url.Values) (matchesQuery, string) {
+	q := matchesQuery{}
+	_, q.spent = values["spent"]
+	_, q.unspent = values["unspent"]
+	_, q.resolveHashes = values["resolve_hashes"]
+	if q.spent && q.unspent {
+		return q, "Invalid query: 'spent' and 'unspent' are mutually exclusive."
+	}
+
+	switch values.Get("order") {
+	case "", "most_recent_first":
+	case "oldest_first":
+		q.oldestFirst = true
+	default:
+		return q, "Invalid query: 'order' must be 'most_recent_first' or 'oldest_first'."
+	}
+
+	for name, field := range map[string]**int{
+		"created_before": &q.createdBefore,
+		"created_after":  &q.createdAfter,
+		"spent_before":   &q.spentBefore,
+		"spent_after":    &q.spentAfter,
+		"output_index":   &q.outputIndex,
+	} {
+		if !values.Has(name) {
+			continue
+		}
+		n, err := strconv.Atoi(values.Get(name))
+		if err != nil || n < 0 {
+			return q, "Invalid query: '" + name + "' must be a positive integer."
+		}
+		*field = &n
+	}
+
+	q.policyID = values.Get("policy_id")
+	q.assetName = values.Get("asset_name")
+	if q.assetName != "" && q.policyID == "" {
+		return q, "Invalid query: 'asset_name' requires 'policy_id'."
+	}
+	q.transactionID = values.Get("transaction_id")
+	if q.outputIndex != nil && q.transactionID == "" {
+		return q, "Invalid query: 'output_index' requires 'transaction_id'."
+	}
+	return q, ""
+}
+
+func (q matchesQuery) accepts(match kugo.Match) bool {
+	spent := match.SpentAt.SlotNo != 0
+	created, spentAt := match.CreatedAt.SlotNo, match.SpentAt.SlotNo
+	switch {
+	case q.spent && !spent, q.unspent && spent:
+		return false
+	case q.createdBefore != nil && created >= *q.createdBefore:
+		return false
+	case q.createdAfter != nil && created <= *q.createdAfter:
+		return false
+	case q.spentBefore != nil && (!spent || spentAt >= *q.spentBefore):
+		return false
+	case q.spentAfter != nil && (!spent || spentAt <= *q.spentAfter):
+		return false
+	case q.policyID != "" && !holds(match, q.policyID, q.assetName):
+		return false
+	case q.transactionID != "" && match.TransactionID != q.transactionID:
+		return false
+	case q.outputIndex != nil && match.OutputIndex != *q.outputIndex:
+		return false
+	}
+	return true
+}
+
+func (s *Server) handleMatches(w http.ResponseWriter, r *http.Request) {
+	pattern := r.PathValue("pattern")
+	if pattern == "" {
+		pattern = "*"
+	}
+	spec, err := kugo.ParsePattern(pattern)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid pattern: "+err.Error())
+		return
+	}
+	if !s.indexes(spec) {
+		writeError(
+			w,
+			http.StatusBadRequest,
+			"The pattern "+pattern+" is not included in the server's configured patterns.",
+		)
+		return
+	}
+	query, hint := parseMatchesQuery(r.URL.Query())
+	if hint != "" {
+		writeError(w, http.StatusBadRequest, hint)
+		return
+	}
+
+	matches := []kugo.Match{}
+	for _, match := range s.matches {
+		if !matchesPattern(spec, match) || !query.accepts(match) {
+			continue
+		}
+		if query.resolveHashes {
+			if match.DatumType == "hash" && match.Datum == "" {
+				match.Datum = s.datums[match.DatumHash]
+			}
+			if match.ScriptHash != "" && match.Script.Script == "" {
+				match.Script = s.scripts[match.ScriptHash]
+			}
+		} else {
+			if match.DatumType == "hash" {
+				match.Datum = ""
+			}
+			match.Script = kugo.Script{}
+		}
+		matches = append(matches, match)
+	}
+
+	slices.SortStableFunc(matches, func(a, b kugo.Match) int {
+		c := cmp.Or(
+			cmp.Compare(a.CreatedAt.SlotNo, b.CreatedAt.SlotNo),
+			cmp.Compare(a.TransactionIndex, b.TransactionIndex),
+			cmp.Compare(a.OutputIndex, b.OutputIndex),
+		)
+		if query.oldestFirst {
+			return c
+		}
+		return -c
+	})
+	writeJSON(w, http.StatusOK, matches)
+}
+
+// indexes reports whether any configured pattern includes spec
+func (s *Server) indexes(spec kugo.PatternSpec) bool {
+	for _, pattern := range s.patterns {
+		if indexed, err := kugo.ParsePattern(pattern); err == nil && includes(indexed, spec) {
+			return true
+		}
+	}
+	return false
+}
+
+// includes reports whether every output matching query also matches
+// indexed; it covers the inclusions kupo itself recognizes
+func includes(indexed, query kugo.PatternSpec) bool {
+	if indexed.Kind == kugo.PatternKindWildcard || indexed.String() == query.String() {
+		return true
+	}
+	switch indexed.Kind {
+	case kugo.PatternKindCredentials:
+		payment, delegation := query.PaymentCredential, query.DelegationCredential
+		switch query.Kind {
+		case kugo.PatternKindCredentials:
+		case kugo.PatternKindAddress:
+			a, err := address.Parse(query.Address)
+			if err != nil {
+				return false
+			}
+			payment, delegation = credential(a.Payment.Hash), "*"
+			if a.Stake != nil {
+				delegation = credential(a.Stake.Hash)
+			}
+		default:
+			return false
+		}
+		return (indexed.PaymentCredential == "*" || strings.EqualFold(indexed.PaymentCredential, payment)) &&
+			(indexed.DelegationCredential == "*" || strings.EqualFold(indexed.DelegationCredential, delegation))
+	case kugo.PatternKindAsset:
+		return query.Kind == kugo.PatternKindAsset &&
+			indexed.AssetName == "*" &&
+			strings.EqualFold(indexed.PolicyID, query.PolicyID)
+	case kugo.PatternKindOutputReference:
+		return query.Kind == kugo.PatternKindOutputReference &&
+			indexed.OutputIndex == nil &&
+			strings.EqualFold(indexed.TransactionID, query.TransactionID)
+	}
+	return false
+}
+
+// matchesPattern reports whether the output matches the pattern;
+// credentials are compared as hex encoded hashes
+func matchesPattern(spec kugo.PatternSpec, match kugo.Match) bool {
+	switch spec.Kind {
+	case kugo.PatternKindWildcard:
+		return true
+	case kugo.PatternKindAddress:
+		return sameAddress(spec.Address, match.Address)
+	case kugo.PatternKindCredentials:
+		a, err := address.Parse(match.Address)
+		if err != nil {
+			return false
+		}
+		if spec.PaymentCredential != "*" &&
+			(a.Payment.Hash == nil || !strings.EqualFold(spec.PaymentCredential, credential(a.Payment.Hash))) {
+			return false
+		}
+		if spec.DelegationCredential != "*" &&
+			(a.Stake == nil || !strings.EqualFold(spec.DelegationCredential, credential(a.Stake.Hash))) {
+			return false
+		}
+		return true
+	case kugo.PatternKindAsset:
+		assetName := spec.AssetName
+		if assetName == "*" {
+			assetName = ""
+		}
+		return holds(match, spec.PolicyID, assetName)
+	case kugo.PatternKindOutputReference:
+		return strings.EqualFold(match.TransactionID, spec.TransactionID) &&
+			(spec.OutputIndex == nil || *spec.OutputIndex == match.OutputIndex)
+	}
+	return false
+}
+
+func credential(hash []byte) string {
+	return hex.EncodeToString(hash)
+}
+
+// sameAddress compares addresses, which may be bech32, base58 or hex
+func sameAddress(a, b string) bool {
+	if a == b {
+		return true
+	}
+	return addressBytes(a) != "" && addressBytes(a) == addressBytes(b)
+}
+
+func addressBytes(s string) string {
+	if raw, err := hex.DecodeString(s); err == nil {
+		return string(raw)
+	}
+	parsed, err := address.Parse(s)
+	if err != nil {
+		return ""
+	}
+	raw, err := parsed.Bytes()
+	if err != nil {
+		return ""
+	}
+	return string(raw)
+}
+
+// holds reports whether the output holds an asset of the policy, or the
+// specific asset if assetName is set
+func holds(match kugo.Match, policyID, assetName string) bool {
+	for policy, assets := range match.Value {
+		if !strings.EqualFold(policy, policyID) {
+			continue
+		}
+		if assetName == "" {
+			return len(assets) > 0
+		}
+		for name := range assets {
+			if strings.EqualFold(name, assetName) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,270 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package kugotest provides an in-memory fake of kupo for tests. It keeps a
+// chain of checkpoints and the outputs created and spent along it, and
+// answers the kupo HTTP API from that state: patterns, matches and their
+// filters, datums, scripts, metadata, checkpoints and health.
+//
+//	server := kugotest.New().
+//		RollForward(kugo.Point{SlotNo: 10, HeaderHash: "aa"}, match).
+//		HTTP()
+//	defer server.Close()
+//	client := kugo.New(kugo.WithEndpoint(server.URL))
+package kugotest
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+
+	"github.com/SundaeSwap-finance/kugo"
+)
+
+// Server is a stateful fake kupo; it is safe for concurrent use, and may be
+// modified while serving requests
+type Server struct {
+	mutex       sync.Mutex
+	checkpoints []kugo.Point // oldest first
+	nodeTip     uint64
+	connected   bool
+	datums      map[string]string
+	matches     []kugo.Match
+	metadata    map[int]map[string][]kugo.Metadatum
+	patterns    []string
+	scripts     map[string]kugo.Script
+}
+
+// New returns an empty server, which indexes everything (the * pattern) and
+// is connected to its node
+func New() *Server {
+	return &Server{
+		connected: true,
+		datums:    map[string]string{},
+		metadata:  map[int]map[string][]kugo.Metadatum{},
+		patterns:  []string{"*"},
+		scripts:   map[string]kugo.Script{},
+	}
+}
+
+// HTTP starts an httptest server backed by s; close it when done
+func (s *Server) HTTP() *httptest.Server {
+	return httptest.NewServer(s.Handler())
+}
+
+// SetPatterns replaces the patterns the server indexes; matches queries for
+// patterns outside these are rejected, as kupo does
+func (s *Server) SetPatterns(patterns ...string) *Server {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.patterns = slices.Clone(patterns)
+	return s
+}
+
+// RollForward extends the chain with a new block at point, creating the
+// given outputs in it; outputs without a CreatedAt are stamped with point
+func (s *Server) RollForward(point kugo.Point, outputs ...kugo.Match) *Server {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.checkpoints = append(s.checkpoints, point)
+	for _, output := range outputs {
+		if output.CreatedAt.SlotNo == 0 {
+			output.CreatedAt = point
+		}
+		s.addMatch(output)
+	}
+	return s
+}
+
+// RollBackward discards every block after slot, along with the outputs
+// created in them, and restores outputs spent in them
+func (s *Server) RollBackward(slot int) *Server {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.checkpoints = slices.DeleteFunc(s.checkpoints, func(point kugo.Point) bool {
+		return point.SlotNo > slot
+	})
+	s.matches = slices.DeleteFunc(s.matches, func(match kugo.Match) bool {
+		return match.CreatedAt.SlotNo > slot
+	})
+	for i := range s.matches {
+		if s.matches[i].SpentAt.SlotNo > slot {
+			s.matches[i].SpentAt = kugo.SpentAt{}
+		}
+	}
+	return s
+}
+
+// AddMatches adds outputs as they are, without extending the chain; any
+// datum or script they carry is also made available by hash
+func (s *Server) AddMatches(matches ...kugo.Match) *Server {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, match := range matches {
+		s.addMatch(match)
+	}
+	return s
+}
+
+func (s *Server) addMatch(match kugo.Match) {
+	if match.DatumHash != "" && match.Datum != "" {
+		s.datums[match.DatumHash] = match.Datum
+	}
+	if match.ScriptHash != "" && match.Script.Script != "" {
+		s.scripts[match.ScriptHash] = match.Script
+	}
+	if match.ScriptHash != "" && match.ResolvedScript.Script != "" {
+		s.scripts[match.ScriptHash] = match.ResolvedScript
+	}
+	// kupo only sends scripts under "script", resolving them from the hash
+	match.ResolvedScript = kugo.Script{}
+	s.matches = append(s.matches, match)
+}
+
+// Spend marks the output index@txID as spent; it reports whether the output
+// exists and was unspent
+func (s *Server) Spend(txID string, index int, at kugo.SpentAt) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, match := range s.matches {
+		if match.TransactionID == txID && match.OutputIndex == index && match.SpentAt.SlotNo == 0 {
+			s.matches[i].SpentAt = at
+			return true
+		}
+	}
+	return false
+}
+
+// AddDatums makes datums, hex encoded, available by their hash
+func (s *Server) AddDatums(datums ...string) *Server {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, datum := range datums {
+		hash, err := kugo.Datum(datum).Hash()
+		if err != nil {
+			panic(fmt.Sprintf("kugotest: %v", err))
+		}
+		s.datums[hex.EncodeToString(hash)] = datum
+	}
+	return s
+}
+
+// AddDatum makes a datum available under the given hash, whether or not it
+// is the datum's actual hash
+func (s *Server) AddDatum(hash, datum string) *Server {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.datums[hash] = datum
+	return s
+}
+
+// AddScripts makes scripts available by their hash
+func (s *Server) AddScripts(scripts ...kugo.Script) *Server {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, script := range scripts {
+		s.scripts[hex.EncodeToString(script.Hash())] = script
+	}
+	return s
+}
+
+// AddMetadata records the metadata of transaction txID, in the block at slot
+func (s *Server) AddMetadata(slot int, txID string, metadata ...kugo.Metadatum) *Server {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.metadata[slot] == nil {
+		s.metadata[slot] = map[string][]kugo.Metadatum{}
+	}
+	s.metadata[slot][txID] = append(s.metadata[slot][txID], metadata...)
+	return s
+}
+
+// SetNodeTip sets the node tip reported by health; by default, the node is
+// assumed to be at the most recent checkpoint
+func (s *Server) SetNodeTip(slot uint64) *Server {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.nodeTip = slot
+	return s
+}
+
+// SetConnected sets whether health reports a connection to the node
+func (s *Server) SetConnected(connected bool) *Server {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.connected = connected
+	return s
+}
+
+// Tip returns the most recent checkpoint, if any
+func (s *Server) Tip() (kugo.Point, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.checkpoints) == 0 {
+		return kugo.Point{}, false
+	}
+	return s.checkpoints[len(s.checkpoints)-1], true
+}
+
+// Handler serves the kupo HTTP API from the server's state
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", s.handleHealth)
+	mux.HandleFunc("GET /v1/checkpoints", s.handleCheckpoints)
+	mux.HandleFunc("GET /v1/checkpoints/{slot}", s.handleCheckpoint)
+	mux.HandleFunc("GET /v1/patterns", s.handlePatterns)
+	mux.HandleFunc("GET /v1/patterns/{pattern...}", s.handlePatternIncluded)
+	mux.HandleFunc("PUT /v1/patterns/{pattern...}", s.handleAddPattern)
+	mux.HandleFunc("DELETE /v1/patterns/{pattern...}", s.handleRemovePattern)
+	mux.HandleFunc("GET /v1/matches", s.handleMatches)
+	mux.HandleFunc("GET /v1/matches/{pattern...}", s.handleMatches)
+	mux.HandleFunc("GET /v1/datums/{hash}", s.handleDatum)
+	mux.HandleFunc("GET /v1/scripts/{hash}", s.handleScript)
+	mux.HandleFunc("GET /v1/metadata/{slot}", s.handleMetadata)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if len(s.checkpoints) > 0 {
+			tip := s.checkpoints[len(s.checkpoints)-1]
+			w.Header().Set("X-Most-Recent-Checkpoint", itoa(tip.SlotNo))
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,287 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugotest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/SundaeSwap-finance/kugo"
+	"github.com/SundaeSwap-finance/kugo/kugotest"
+	"github.com/SundaeSwap-finance/ogmigo/v6/ouroboros/chainsync/num"
+	"github.com/SundaeSwap-finance/ogmigo/v6/ouroboros/shared"
+	"github.com/tj/assert"
+)
+
+const (
+	keyAddress    = "addr1qx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3n0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgse35a3x"
+	scriptAddress = "addr1w8phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gtcyjy7wx"
+	paymentKey    = "9493315cd92eb5d8c4304e67b7e16ae36d61d34502694657811a2c8e"
+	stakeKey      = "337b62cfff6403a06a3acbc34f8c46003c69fe79a3628cefa9c47251"
+	policyID      = "c37b1b5dc0669f1d3c61a6fddb2e8fde96be87b881c60bce8e8d542f"
+	unitDatum     = "d87980"
+	unitDatumHash = "923918e403bf43c34b4ef6b48eb2ee04babed17320d8d1b9ff9ad086e86f44ec"
+	scriptHash    = "67f33146617a5e61936081db3b2117cbf59bd2123748f58ac9678656"
+)
+
+var script = kugo.Script{
+	Language: kugo.ScriptLanguagePlutusV1,
+	Script:   "4d01000033222220051200120011",
+}
+
+func txID(b byte) string {
+	return strings.Repeat(string("0123456789abcdef"[b%16]), 64)
+}
+
+func value(lovelace int64, assets ...string) kugo.Value {
+	v := shared.ValueFromCoins(shared.CreateAdaCoin(num.Int64(lovelace)))
+	for _, asset := range assets {
+		v.AddAsset(shared.Coin{AssetId: shared.AssetID(policyID + "." + asset), Amount: num.Int64(1)})
+	}
+	return kugo.Value(v)
+}
+
+// newChain builds a small chain:
+//
+//	slot 10: tx 1 pays keyAddress, spent at slot 20
+//	slot 20: tx 2 pays scriptAddress with a token, a datum hash and a
+//	         reference script
+//	slot 30: tx 3 pays keyAddress
+func newChain() *kugotest.Server {
+	server := kugotest.New().
+		RollForward(
+			kugo.Point{SlotNo: 10, HeaderHash: "aa"},
+			kugo.Match{TransactionID: txID(1), Address: keyAddress, Value: value(1_000_000)},
+		).
+		RollForward(
+			kugo.Point{SlotNo: 20, HeaderHash: "bb"},
+			kugo.Match{
+				TransactionID: txID(2),
+				Address:       scriptAddress,
+				Value:         value(2_000_000, "cafe"),
+				DatumHash:     unitDatumHash,
+				DatumType:     "hash",
+				ScriptHash:    scriptHash,
+			},
+		).
+		RollForward(
+			kugo.Point{SlotNo: 30, HeaderHash: "cc"},
+			kugo.Match{TransactionID: txID(3), OutputIndex: 1, Address: keyAddress, Value: value(3_000_000)},
+		).
+		AddDatums(unitDatum).
+		AddScripts(script)
+	server.Spend(txID(1), 0, kugo.SpentAt{SlotNo: 20, HeaderHash: "bb", TransactionId: txID(2)})
+	return server
+}
+
+func txIDs(matches []kugo.Match) []string {
+	var ids []string
+	for _, match := range matches {
+		ids = append(ids, match.TransactionID[:1])
+	}
+	return ids
+}
+
+func TestServer_Matches(t *testing.T) {
+	t.Parallel()
+
+	server := newChain().HTTP()
+	defer server.Close()
+	client := kugo.New(kugo.WithEndpoint(server.URL))
+	ctx := context.Background()
+
+	testCases := map[string]struct {
+		Filters []kugo.MatchesFilter
+		Want    []string
+	}{
+		"everything, most recent first": {nil, []string{"3", "2", "1"}},
+		"unspent":                       {[]kugo.MatchesFilter{kugo.OnlyUnspent()}, []string{"3", "2"}},
+		"spent":                         {[]kugo.MatchesFilter{kugo.OnlySpent()}, []string{"1"}},
+		"address":                       {[]kugo.MatchesFilter{kugo.Address(keyAddress)}, []string{"3", "1"}},
+		"payment credential":            {[]kugo.MatchesFilter{kugo.Matching(kugo.PaymentCredentialPattern(paymentKey))}, []string{"3", "1"}},
+		"delegation credential":         {[]kugo.MatchesFilter{kugo.Matching(kugo.DelegationCredentialPattern(stakeKey))}, []string{"3", "1"}},
+		"policy":                        {[]kugo.MatchesFilter{kugo.PolicyID(policyID)}, []string{"2"}},
+		"asset":                         {[]kugo.MatchesFilter{kugo.Matching(kugo.AssetPattern(policyID, "cafe"))}, []string{"2"}},
+		"missing asset":                 {[]kugo.MatchesFilter{kugo.Matching(kugo.AssetPattern(policyID, "beef"))}, nil},
+		"policy on address":             {[]kugo.MatchesFilter{kugo.Address(scriptAddress), kugo.PolicyID(policyID)}, []string{"2"}},
+		"transaction":                   {[]kugo.MatchesFilter{kugo.Transaction(txID(3))}, []string{"3"}},
+		"created after":                 {[]kugo.MatchesFilter{kugo.CreatedAfter(10)}, []string{"3", "2"}},
+		"created before":                {[]kugo.MatchesFilter{kugo.CreatedBefore(30)}, []string{"2", "1"}},
+		"spent after":                   {[]kugo.MatchesFilter{kugo.SpentAfter(15)}, []string{"1"}},
+		"spent before":                  {[]kugo.MatchesFilter{kugo.SpentBefore(20)}, nil},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			matches, err := client.Matches(ctx, tc.Filters...)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.Want, txIDs(matches))
+		})
+	}
+
+	t.Run("resolve hashes", func(t *testing.T) {
+		matches, err := client.Matches(ctx, kugo.Address(scriptAddress))
+		assert.Nil(t, err)
+		assert.Equal(t, "", matches[0].Datum)
+
+		matches, err = client.Matches(ctx, kugo.Address(scriptAddress), kugo.ResolveHashes())
+		assert.Nil(t, err)
+		assert.Equal(t, unitDatum, matches[0].Datum)
+		assert.Equal(t, script, matches[0].ResolvedScript)
+		assert.Equal(t, kugo.Script{}, matches[0].Script)
+
+		for match, err := range client.MatchesStream(
+			ctx,
+			kugo.Address(scriptAddress),
+			kugo.ResolveHashes(),
+		) {
+			assert.Nil(t, err)
+			assert.Equal(t, script, match.ResolvedScript)
+			assert.Equal(t, kugo.Script{}, match.Script)
+		}
+	})
+}
+
+func TestServer_Patterns(t *testing.T) {
+	t.Parallel()
+
+	fake := newChain().SetPatterns(paymentKey + "/*")
+	server := fake.HTTP()
+	defer server.Close()
+	client := kugo.New(kugo.WithEndpoint(server.URL))
+	ctx := context.Background()
+
+	matches, err := client.Matches(ctx, kugo.Address(keyAddress))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"3", "1"}, txIDs(matches))
+
+	_, err = client.Matches(ctx, kugo.Address(scriptAddress))
+	assert.True(t, errors.Is(err, kugo.ErrPatternNotIndexed))
+	// An invalid pattern is a different mistake
+	_, err = client.Matches(ctx, kugo.Pattern("not-a-pattern"))
+	assert.NotNil(t, err)
+	assert.False(t, errors.Is(err, kugo.ErrPatternNotIndexed))
+
+	included, err := client.PatternIncluded(ctx, kugo.CredentialsPattern(paymentKey, stakeKey))
+	assert.Nil(t, err)
+	assert.True(t, included)
+
+	patterns, err := client.AddPattern(
+		ctx,
+		kugo.PolicyPattern(policyID),
+		kugo.RollbackTo(kugo.Point{SlotNo: 0}),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{paymentKey + "/*", policyID + ".*"}, patterns)
+
+	matches, err = client.Matches(ctx, kugo.PolicyID(policyID))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"2"}, txIDs(matches))
+
+	deleted, err := client.RemovePattern(ctx, kugo.PolicyPattern(policyID))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, deleted)
+}
+
+func TestServer_Chain(t *testing.T) {
+	t.Parallel()
+
+	fake := newChain()
+	server := fake.HTTP()
+	defer server.Close()
+	client := kugo.New(kugo.WithEndpoint(server.URL))
+	ctx := context.Background()
+
+	points, err := client.Checkpoints(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []kugo.Point{{SlotNo: 30, HeaderHash: "cc"}, {SlotNo: 20, HeaderHash: "bb"}, {SlotNo: 10, HeaderHash: "aa"}}, points)
+
+	points, err = client.Checkpoints(ctx, kugo.BySlot(25))
+	assert.Nil(t, err)
+	assert.Equal(t, []kugo.Point{{SlotNo: 20, HeaderHash: "bb"}}, points)
+
+	var info kugo.ResponseInfo
+	_, err = client.Matches(kugo.RecordResponseInfo(ctx, &info))
+	assert.Nil(t, err)
+	assert.EqualValues(t, 30, info.MostRecentCheckpoint)
+
+	// Rolling back to slot 15 drops tx 2 and 3, and restores tx 1
+	fake.RollBackward(15)
+	matches, err := client.Matches(ctx, kugo.OnlyUnspent())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1"}, txIDs(matches))
+	tip, ok := fake.Tip()
+	assert.True(t, ok)
+	assert.Equal(t, kugo.Point{SlotNo: 10, HeaderHash: "aa"}, tip)
+}
+
+func TestServer_Resources(t *testing.T) {
+	t.Parallel()
+
+	metadatum := kugo.Metadatum{Hash: "ab", Raw: "a0", Schema: json.RawMessage(`{}`)}
+	server := newChain().
+		AddMetadata(20, txID(2), metadatum).
+		HTTP()
+	defer server.Close()
+	client := kugo.New(kugo.WithEndpoint(server.URL), kugo.WithDatumVerification())
+	ctx := context.Background()
+
+	datum, err := client.Datum(ctx, unitDatumHash)
+	assert.Nil(t, err)
+	assert.Equal(t, unitDatum, datum)
+
+	got, err := client.Script(ctx, scriptHash)
+	assert.Nil(t, err)
+	assert.Equal(t, script, *got)
+
+	metadata, err := client.Metadata(ctx, 20, txID(2))
+	assert.Nil(t, err)
+	assert.Equal(t, []kugo.Metadatum{metadatum}, metadata)
+
+	metadata, err = client.Metadata(ctx, 20, txID(3))
+	assert.Nil(t, err)
+	assert.Len(t, metadata, 0)
+}
+
+func TestServer_Health(t *testing.T) {
+	t.Parallel()
+
+	fake := newChain().SetNodeTip(40)
+	server := fake.HTTP()
+	defer server.Close()
+	client := kugo.New(kugo.WithEndpoint(server.URL))
+
+	health, err := client.Health(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, health.Connected())
+	assert.EqualValues(t, 10, health.SyncLag)
+	assert.True(t, health.Synced(10))
+
+	fake.SetConnected(false)
+	health, err = client.Health(context.Background())
+	assert.Nil(t, err)
+	assert.False(t, health.Connected())
+}
@@ -0,0 +1,200 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// Cache stores values by key; implementations must be safe for concurrent
+// use. Clients keep datums and scripts in one with WithDatumCache and
+// WithScriptCache, as does the middleware package's Caching.
+type Cache interface {
+	Get(key string) (any, bool)
+	Set(key string, value any)
+}
+
+// CacheLimits bounds an in-memory cache; the least recently used entries are
+// evicted once either limit is exceeded. A zero limit is not enforced, and
+// if both are zero, MaxEntries defaults to 1024.
+type CacheLimits struct {
+	MaxEntries int
+	// MaxBytes bounds the total size of the cached datums or scripts, in
+	// decoded bytes; other values don't count towards it
+	MaxBytes int
+}
+
+func (l CacheLimits) withDefaults() CacheLimits {
+	if l.MaxEntries <= 0 && l.MaxBytes <= 0 {
+		l.MaxEntries = 1024
+	}
+	return l
+}
+
+// LRUCache is a size bounded Cache, evicting the least recently used
+// entries first
+type LRUCache struct {
+	limits CacheLimits
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // most recently used first
+	bytes   int
+}
+
+type lruEntry struct {
+	key   string
+	value any
+	size  int
+}
+
+// NewLRUCache returns an empty cache bounded by limits
+func NewLRUCache(limits CacheLimits) *LRUCache {
+	return &LRUCache{
+		limits:  limits.withDefaults(),
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+	}
+}
+
+// cacheSize is the number of bytes value counts for towards MaxBytes
+func cacheSize(value any) int {
+	switch v := value.(type) {
+	case string: // hex encoded datums
+		return len(v) / 2
+	case Script:
+		return len(v.Script) / 2
+	case []byte:
+		return len(v)
+	default:
+		return 0
+	}
+}
+
+func (c *LRUCache) Get(key string) (any, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*lruEntry).value, true
+}
+
+// Set adds value to the cache, evicting older entries to make room; values
+// larger than MaxBytes aren't kept
+func (c *LRUCache) Set(key string, value any) {
+	size := cacheSize(value)
+	if c.limits.MaxBytes > 0 && size > c.limits.MaxBytes {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*lruEntry)
+		c.bytes += size - entry.size
+		entry.value, entry.size = value, size
+		c.order.MoveToFront(element)
+	} else {
+		entry := &lruEntry{key: key, value: value, size: size}
+		c.entries[key] = c.order.PushFront(entry)
+		c.bytes += size
+	}
+
+	for (c.limits.MaxEntries > 0 && c.order.Len() > c.limits.MaxEntries) ||
+		(c.limits.MaxBytes > 0 && c.bytes > c.limits.MaxBytes) {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*lruEntry)
+		c.order.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.bytes -= entry.size
+	}
+}
+
+// Len returns the number of entries in the cache
+func (c *LRUCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.order.Len()
+}
+
+// cached returns the value cache holds for key, or fetches it, sharing the
+// fetch with any concurrent lookups of the same key. The fetch outlives a
+// caller that gives up, so the others still get their answer, but is bounded
+// by the client's timeout; it doesn't record response info, since it
+// belongs to no caller in particular. Values fetch reports as not
+// cacheable, such as unknown hashes, are returned but not kept.
+func cached[V any](
+	ctx context.Context,
+	c *Client,
+	cache Cache,
+	key string,
+	fetch func(context.Context) (value V, cacheable bool, err error),
+) (V, error) {
+	if value, ok := cache.Get(key); ok {
+		if v, ok := value.(V); ok {
+			return v, nil
+		}
+	}
+
+	results := c.flights.DoChan(key, func() (any, error) {
+		timeout := c.options.timeout
+		if timeout <= 0 {
+			timeout = defaultTimeout
+		}
+		shared, cancel := context.WithTimeout(
+			context.WithValue(
+				context.WithoutCancel(ctx),
+				responseInfoKey{},
+				(*responseInfoRecorder)(nil),
+			),
+			timeout,
+		)
+		defer cancel()
+
+		value, cacheable, err := fetch(shared)
+		if err == nil && cacheable {
+			cache.Set(key, value)
+		}
+		return value, err
+	})
+	select {
+	case result := <-results:
+		if result.Err != nil {
+			var zero V
+			return zero, result.Err
+		}
+		return result.Val.(V), nil
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
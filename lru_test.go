@@ -0,0 +1,251 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SundaeSwap-finance/kugo"
+	"github.com/SundaeSwap-finance/kugo/kugotest"
+	"github.com/tj/assert"
+)
+
+func TestLRUCache_Eviction(t *testing.T) {
+	t.Run("by entries", func(t *testing.T) {
+		t.Parallel()
+
+		cache := kugo.NewLRUCache(kugo.CacheLimits{MaxEntries: 2})
+		cache.Set("a", "01")
+		cache.Set("b", "02")
+		_, _ = cache.Get("a") // b is now least recently used
+		cache.Set("c", "03")
+
+		_, ok := cache.Get("b")
+		assert.False(t, ok)
+		value, ok := cache.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, "01", value)
+		assert.Equal(t, 2, cache.Len())
+	})
+
+	t.Run("by bytes", func(t *testing.T) {
+		t.Parallel()
+
+		// Datums and scripts count their decoded size
+		cache := kugo.NewLRUCache(kugo.CacheLimits{MaxBytes: 4})
+		cache.Set("a", "1111")
+		cache.Set("b", kugo.Script{Script: "2222"})
+		cache.Set("c", "33")
+		_, ok := cache.Get("a")
+		assert.False(t, ok)
+		assert.Equal(t, 2, cache.Len())
+
+		// Values larger than the whole cache aren't kept
+		cache.Set("d", []byte("55555"))
+		_, ok = cache.Get("d")
+		assert.False(t, ok)
+		assert.Equal(t, 2, cache.Len())
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(
+			t,
+			kugo.CacheLimits{MaxEntries: 1024},
+			kugo.CacheLimitsWithDefaults(kugo.CacheLimits{}),
+		)
+		assert.Equal(
+			t,
+			kugo.CacheLimits{MaxBytes: 10},
+			kugo.CacheLimitsWithDefaults(kugo.CacheLimits{MaxBytes: 10}),
+		)
+	})
+}
+
+func TestClient_DatumCache(t *testing.T) {
+	t.Parallel()
+
+	server := kugotest.New().AddDatum("aaaa", "d87980").HTTP()
+	defer server.Close()
+	transport := &countingTransport{next: http.DefaultTransport}
+	client := kugo.New(
+		kugo.WithEndpoint(server.URL),
+		kugo.WithTransport(transport),
+		kugo.WithDatumCache(kugo.CacheLimits{MaxEntries: 10}),
+	)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		datum, err := client.Datum(ctx, "aaaa")
+		assert.Nil(t, err)
+		assert.Equal(t, "d87980", datum)
+	}
+	assert.EqualValues(t, 1, transport.requests.Load())
+
+	// Unknown datums are looked up each time
+	for i := 0; i < 2; i++ {
+		datum, err := client.Datum(ctx, "bbbb")
+		assert.Nil(t, err)
+		assert.Equal(t, "", datum)
+	}
+	assert.EqualValues(t, 3, transport.requests.Load())
+}
+
+func TestClient_ScriptCache(t *testing.T) {
+	t.Parallel()
+
+	script := kugo.Script{
+		Language: kugo.ScriptLanguagePlutusV1,
+		Script:   "4d01000033222220051200120011",
+	}
+	server := kugotest.New().AddScripts(script).HTTP()
+	defer server.Close()
+	transport := &countingTransport{next: http.DefaultTransport}
+	client := kugo.New(
+		kugo.WithEndpoint(server.URL),
+		kugo.WithTransport(transport),
+		kugo.WithScriptCache(kugo.CacheLimits{}),
+	)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		got, err := client.Script(
+			ctx,
+			"67f33146617a5e61936081db3b2117cbf59bd2123748f58ac9678656",
+		)
+		assert.Nil(t, err)
+		assert.Equal(t, script, *got)
+		got.Script = "" // the cached copy is unaffected
+	}
+	assert.EqualValues(t, 1, transport.requests.Load())
+
+	got, err := client.Script(
+		ctx,
+		"4fc6bb0c93780ad706425d9f7dc1d3c5e3ddbf29ba8486dce904a5fc",
+	)
+	assert.Nil(t, err)
+	assert.Nil(t, got)
+}
+
+func TestClient_DatumCacheSingleflight(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	var (
+		mutex    sync.Mutex
+		requests int
+	)
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			requests++
+			mutex.Unlock()
+			<-release
+			_, _ = w.Write([]byte(`{"datum":"d87980"}`))
+		}),
+	)
+	defer server.Close()
+	client := kugo.New(
+		kugo.WithEndpoint(server.URL),
+		kugo.WithDatumCache(kugo.CacheLimits{}),
+	)
+
+	// A caller that gives up doesn't affect the others
+	canceled, cancel := context.WithTimeout(
+		context.Background(),
+		10*time.Millisecond,
+	)
+	defer cancel()
+	_, err := client.Datum(canceled, "aaaa")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			datum, err := client.Datum(context.Background(), "aaaa")
+			assert.Nil(t, err)
+			assert.Equal(t, "d87980", datum)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, 1, requests)
+}
+
+func TestClient_DatumCacheDeadline(t *testing.T) {
+	t.Parallel()
+
+	hung := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			<-hung
+		},
+	))
+	defer server.Close()
+	defer close(hung)
+
+	// The http client has no timeout of its own, so only the shared
+	// fetch's deadline ends the request
+	client := kugo.New(
+		kugo.WithEndpoint(server.URL),
+		kugo.WithHTTPClient(&http.Client{}),
+		kugo.WithTimeout(50*time.Millisecond),
+		kugo.WithDatumCache(kugo.CacheLimits{}),
+	)
+	_, err := client.Datum(context.Background(), "aaaa")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestClient_DatumCacheResponseInfo(t *testing.T) {
+	t.Parallel()
+
+	server := kugotest.New().
+		RollForward(kugo.Point{SlotNo: 1234, HeaderHash: "aa"}).
+		AddDatum("aaaa", "d87980").
+		HTTP()
+	defer server.Close()
+	client := kugo.New(
+		kugo.WithEndpoint(server.URL),
+		kugo.WithDatumCache(kugo.CacheLimits{}),
+	)
+
+	var info kugo.ResponseInfo
+	ctx := kugo.RecordResponseInfo(context.Background(), &info)
+	_, err := client.Datum(ctx, "aaaa")
+	assert.Nil(t, err)
+	assert.Equal(t, kugo.ResponseInfo{}, info)
+}
@@ -28,7 +28,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
+	"iter"
 	"net/http"
 	"net/url"
 	"time"
@@ -39,18 +39,21 @@ import (
 )
 
 type matchesOptions struct {
-	spent     bool
-	unspent   bool
-	pattern   string
-	policyId  string
-	assetName string
-	txHash    string
-	txIx      *int // This is a pointer so we can distinguish between null and 0
+	spent         bool
+	unspent       bool
+	resolveHashes bool
+	pattern       string
+	policyId      string
+	assetName     string
+	txHash        string
+	txIx          *int // This is a pointer so we can distinguish between null and 0
 	// Pagination properties
 	created_before uint64
 	spent_before   uint64
 	created_after  uint64
 	spent_after    uint64
+	// err records an invalid filter, so it can be surfaced before querying
+	err error
 }
 
 func (o matchesOptions) apply(url *url.URL) {
@@ -65,6 +68,13 @@ func (o matchesOptions) apply(url *url.URL) {
 		qs += "unspent"
 	}
 
+	if o.resolveHashes {
+		if qs != "" {
+			qs += "&"
+		}
+		qs += "resolve_hashes"
+	}
+
 	// Handle the pagination query params
 	if o.created_before != 0 {
 		if qs != "" {
@@ -166,6 +176,147 @@ func (c *Client) Matches(
 		)
 	}()
 
+	req, err := c.newMatchesRequest(ctx, filters...)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch matches: %w", err)
+	}
+	if resp == nil {
+		return nil, errors.New("failed with a nil response")
+	}
+	defer resp.Body.Close()
+	body, err := readResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	matches = []Match{}
+	if err := json.Unmarshal(body, &matches); err != nil {
+		return nil, fmt.Errorf("unable to parse body %v: %w", string(body), err)
+	}
+	resolved := resolvesHashes(filters)
+	for i := range matches {
+		if resolved {
+			matches[i].moveResolvedScript()
+		}
+		if err := c.verifyMatch(matches[i]); err != nil {
+			return nil, err
+		}
+	}
+	return matches, nil
+}
+
+// MatchesStream is like Matches, but decodes one match at a time as the
+// response is read, so memory use stays constant regardless of how many
+// matches kupo returns. Iteration stops after the first error.
+func (c *Client) MatchesStream(
+	ctx context.Context,
+	filters ...MatchesFilter,
+) iter.Seq2[Match, error] {
+	return func(yield func(Match, error) bool) {
+		var (
+			start   = time.Now()
+			matched = 0
+			err     error
+		)
+		defer func() {
+			errStr := ""
+			if err != nil {
+				errStr = err.Error()
+			}
+			c.options.logger.Debug(
+				"MatchesStream() finished",
+				ogmigo.KV(
+					"duration",
+					time.Since(start).Round(time.Millisecond).String(),
+				),
+				ogmigo.KV("matched", fmt.Sprintf("%v", matched)),
+				ogmigo.KV("err", errStr),
+			)
+		}()
+
+		err = c.streamMatches(ctx, filters, func(match Match) bool {
+			matched++
+			return yield(match, nil)
+		})
+		if err != nil {
+			yield(Match{}, err)
+		}
+	}
+}
+
+// streamMatches decodes the matches response element by element, handing
+// each to fn until fn returns false
+func (c *Client) streamMatches(
+	ctx context.Context,
+	filters []MatchesFilter,
+	fn func(Match) bool,
+) error {
+	req, err := c.newMatchesRequest(ctx, filters...)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("unable to fetch matches: %w", err)
+	}
+	if resp == nil {
+		return errors.New("failed with a nil response")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		_, err := readResponse(resp)
+		return err
+	}
+
+	resolved := resolvesHashes(filters)
+	decoder := json.NewDecoder(resp.Body)
+	if err := expectDelim(decoder, '['); err != nil {
+		return err
+	}
+	for decoder.More() {
+		var match Match
+		if err := decoder.Decode(&match); err != nil {
+			return fmt.Errorf("unable to parse match: %w", err)
+		}
+		if resolved {
+			match.moveResolvedScript()
+		}
+		if err := c.verifyMatch(match); err != nil {
+			return err
+		}
+		if !fn(match) {
+			return nil
+		}
+	}
+	return expectDelim(decoder, ']')
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("unable to parse matches: %w", err)
+	}
+	if token != delim {
+		return fmt.Errorf(
+			"unable to parse matches: expected '%v', got '%v'",
+			delim,
+			token,
+		)
+	}
+	return nil
+}
+
+// newMatchesRequest builds the GET /v1/matches request for a set of filters
+func (c *Client) newMatchesRequest(
+	ctx context.Context,
+	filters ...MatchesFilter,
+) (*http.Request, error) {
 	url, err := url.Parse(c.options.endpoint)
 	if err != nil {
 		return nil, fmt.Errorf(
@@ -180,6 +331,9 @@ func (c *Client) Matches(
 	for _, f := range filters {
 		f(&o)
 	}
+	if o.err != nil {
+		return nil, o.err
+	}
 	if url == nil {
 		return nil, fmt.Errorf("nil url returned for endpoint: %v", c.options.endpoint)
 	}
@@ -192,30 +346,7 @@ func (c *Client) Matches(
 		return nil, fmt.Errorf("unable to build request: %w", err)
 	}
 
-	req.Close = true
-	req = req.WithContext(ctx)
-
-	client := &http.Client{
-		Timeout: c.options.timeout,
-	}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("unable to fetch matches: %w", err)
-	}
-	if resp == nil {
-		return nil, errors.New("failed with a nil response")
-	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %w", err)
-	}
-
-	matches = []Match{}
-	if err := json.Unmarshal(body, &matches); err != nil {
-		return nil, fmt.Errorf("unable to parse body %v: %w", string(body), err)
-	}
-	return matches, nil
+	return req.WithContext(ctx), nil
 }
 
 func All() MatchesFilter {
@@ -239,6 +370,31 @@ func OnlyUnspent() MatchesFilter {
 	}
 }
 
+// ResolveHashes asks kupo to include the datum and script of each match,
+// rather than just their hashes; they're returned in Datum and ResolvedScript
+func ResolveHashes() MatchesFilter {
+	return func(o *matchesOptions) {
+		o.resolveHashes = true
+	}
+}
+
+// resolvesHashes reports whether the filters include ResolveHashes
+func resolvesHashes(filters []MatchesFilter) bool {
+	o := matchesOptions{}
+	for _, f := range filters {
+		f(&o)
+	}
+	return o.resolveHashes
+}
+
+// moveResolvedScript moves the script kupo resolved from the match's script
+// hash into ResolvedScript, where callers can tell it from an inlined one
+func (m *Match) moveResolvedScript() {
+	if m.ScriptHash != "" && m.Script.Script != "" {
+		m.ResolvedScript, m.Script = m.Script, Script{}
+	}
+}
+
 func Pattern(pattern string) MatchesFilter {
 	return func(o *matchesOptions) {
 		o.pattern = pattern
@@ -251,6 +407,18 @@ func Address(address string) MatchesFilter {
 	}
 }
 
+// Matching filters to a typed pattern; an invalid pattern is reported by
+// Matches before any request is made
+func Matching(spec PatternSpec) MatchesFilter {
+	return func(o *matchesOptions) {
+		if err := spec.Validate(); err != nil {
+			o.err = fmt.Errorf("invalid pattern: %w", err)
+			return
+		}
+		o.pattern = spec.String()
+	}
+}
+
 func Transaction(txHash string) MatchesFilter {
 	return func(o *matchesOptions) {
 		o.txHash = txHash
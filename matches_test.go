@@ -21,45 +21,48 @@
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
 // SOFTWARE.
 
-package kugo
+package kugo_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"testing"
 
+	"github.com/SundaeSwap-finance/kugo"
+	"github.com/SundaeSwap-finance/kugo/kugotest"
 	"github.com/SundaeSwap-finance/ogmigo/v6/ouroboros/chainsync"
+	"github.com/SundaeSwap-finance/ogmigo/v6/ouroboros/chainsync/num"
 	"github.com/SundaeSwap-finance/ogmigo/v6/ouroboros/shared"
-
 	"github.com/tj/assert"
 )
 
 func Test_Matches(t *testing.T) {
 	t.Parallel()
-	match := Match{
+	match := kugo.Match{
 		TransactionIndex: 1,
 		TransactionID:    "abcdef",
 		OutputIndex:      0,
 		Address:          "addr_test1qpluezahtqdtwg4f7qewdvjvz806hsatqwr4u04yzcrk2m7pucvj7jyhq97rca9m0wul2fu3qnsayxvqdwlda8wngurqgyfepe",
+		Value: kugo.Value(shared.ValueFromCoins(shared.Coin{
+			AssetId: "4fc16c94d066e949e771c5581235f8090ad6aaffaf373a426445ca51.73636f6f70209a0a",
+			Amount:  num.Int64(1),
+		})),
 	}
-	server := NewMockServer().AddMatches(
-		"addr_test1qpluezahtqdtwg4f7qewdvjvz806hsatqwr4u04yzcrk2m7pucvj7jyhq97rca9m0wul2fu3qnsayxvqdwlda8wngurqgyfepe",
-		match,
-	).
-		HTTP()
+	server := kugotest.New().AddMatches(match).HTTP()
 	defer server.Close()
 
-	c := New(WithEndpoint(server.URL))
+	c := kugo.New(kugo.WithEndpoint(server.URL))
 	matches, err := c.Matches(
 		context.Background(),
-		OnlyUnspent(),
-		AssetID(
+		kugo.OnlyUnspent(),
+		kugo.AssetID(
 			shared.AssetID(
 				"4fc16c94d066e949e771c5581235f8090ad6aaffaf373a426445ca51.73636f6f70209a0a",
 			),
 		),
-		Pattern(
+		kugo.Pattern(
 			"addr_test1qpluezahtqdtwg4f7qewdvjvz806hsatqwr4u04yzcrk2m7pucvj7jyhq97rca9m0wul2fu3qnsayxvqdwlda8wngurqgyfepe",
 		),
 	)
@@ -72,7 +75,7 @@ func Test_Matches(t *testing.T) {
 func Test_Options(t *testing.T) {
 	type testCase struct {
 		label    string
-		options  []MatchesFilter
+		options  []kugo.MatchesFilter
 		expected string
 	}
 
@@ -80,82 +83,106 @@ func Test_Options(t *testing.T) {
 	testCases := []testCase{
 		{
 			label:    "none",
-			options:  []MatchesFilter{},
+			options:  []kugo.MatchesFilter{},
 			expected: base + "",
 		},
 		{
 			label:    "spent",
-			options:  []MatchesFilter{OnlySpent()},
+			options:  []kugo.MatchesFilter{kugo.OnlySpent()},
 			expected: base + "?spent",
 		},
 		{
 			label:    "unspent",
-			options:  []MatchesFilter{OnlyUnspent()},
+			options:  []kugo.MatchesFilter{kugo.OnlyUnspent()},
 			expected: base + "?unspent",
 		},
+		{
+			label: "resolve hashes",
+			options: []kugo.MatchesFilter{
+				kugo.OnlyUnspent(),
+				kugo.ResolveHashes(),
+			},
+			expected: base + "?unspent&resolve_hashes",
+		},
 		{
 			label:    "overlapping",
-			options:  []MatchesFilter{Overlapping(123)},
+			options:  []kugo.MatchesFilter{kugo.Overlapping(123)},
 			expected: base + "?created_before=123&spent_after=123",
 		},
 		{
 			label:    "policy",
-			options:  []MatchesFilter{PolicyID("abc")},
+			options:  []kugo.MatchesFilter{kugo.PolicyID("abc")},
 			expected: base + "/abc.%2A", // NOTE(pi): '*' url-encodes as %2A
 		},
 		{
-			label:    "assetId",
-			options:  []MatchesFilter{AssetID(shared.AssetID("abc.xyz"))},
+			label: "assetId",
+			options: []kugo.MatchesFilter{
+				kugo.AssetID(shared.AssetID("abc.xyz")),
+			},
 			expected: base + "/abc.xyz",
 		},
 		{
 			label:    "transaction",
-			options:  []MatchesFilter{Transaction("xyz")},
+			options:  []kugo.MatchesFilter{kugo.Transaction("xyz")},
 			expected: base + "/%2A@xyz", // NOTE(pi): '*' url-encodes as %2A
 		},
 		{
-			label:    "txOut",
-			options:  []MatchesFilter{TxOut(chainsync.NewTxID("xyz", 1))},
+			label: "txOut",
+			options: []kugo.MatchesFilter{
+				kugo.TxOut(chainsync.NewTxID("xyz", 1)),
+			},
 			expected: base + "/1@xyz", // NOTE(pi): '*' url-encodes as %2A
 		},
 		{
 			label:    "pattern",
-			options:  []MatchesFilter{Pattern("www")},
+			options:  []kugo.MatchesFilter{kugo.Pattern("www")},
 			expected: base + "/www",
 		},
+		{
+			label: "matching",
+			options: []kugo.MatchesFilter{
+				kugo.Matching(
+					kugo.OutputReferencePattern(
+						1,
+						"2222222222222222222222222222222222222222222222222222222222222222",
+					),
+				),
+			},
+			expected: base + "/1@2222222222222222222222222222222222222222222222222222222222222222",
+		},
 		{
 			label: "mixed",
-			options: []MatchesFilter{
-				Overlapping(123),
-				AssetID(shared.AssetID("abc.xyz")),
-				Pattern("www"),
+			options: []kugo.MatchesFilter{
+				kugo.Overlapping(123),
+				kugo.AssetID(shared.AssetID("abc.xyz")),
+				kugo.Pattern("www"),
 			},
 			expected: base + "/www?created_before=123&spent_after=123&policy_id=abc&asset_name=xyz",
 		},
 		{
 			label: "mixed 2",
-			options: []MatchesFilter{
-				Overlapping(123),
-				PolicyID("abc"),
-				Pattern("www"),
+			options: []kugo.MatchesFilter{
+				kugo.Overlapping(123),
+				kugo.PolicyID("abc"),
+				kugo.Pattern("www"),
 			},
 			expected: base + "/www?created_before=123&spent_after=123&policy_id=abc",
 		},
 		{
 			label: "mixed 3",
-			options: []MatchesFilter{
-				Overlapping(123),
-				TxOut(chainsync.NewTxID("xyz", 1)),
-				Pattern("www"),
+			options: []kugo.MatchesFilter{
+				kugo.Overlapping(123),
+				kugo.TxOut(chainsync.NewTxID("xyz", 1)),
+				kugo.Pattern("www"),
 			},
 			expected: base + "/www?created_before=123&spent_after=123&transaction_id=xyz&output_index=1",
 		},
 		{
 			label: "mixed 4",
-			options: []MatchesFilter{
-				Overlapping(123),
-				Transaction("xyz"),
-				Pattern("www"),
+			options: []kugo.MatchesFilter{
+				kugo.Overlapping(123),
+				kugo.Transaction("xyz"),
+				kugo.Pattern("www"),
 			},
 			expected: base + "/www?created_before=123&spent_after=123&transaction_id=xyz",
 		},
@@ -163,11 +190,60 @@ func Test_Options(t *testing.T) {
 	for _, tc := range testCases {
 		reqUrl, err := url.Parse(base)
 		assert.Nil(t, err)
-		opts := matchesOptions{}
-		for _, o := range tc.options {
-			o(&opts)
-		}
-		opts.apply(reqUrl)
+		kugo.ApplyMatchesFilters(reqUrl, tc.options...)
 		assert.Equal(t, tc.expected, reqUrl.String(), tc.label)
 	}
 }
+
+func Test_MatchingInvalidPattern(t *testing.T) {
+	c := kugo.New(kugo.WithEndpoint("http://localhost:1442"))
+	_, err := c.Matches(
+		context.Background(),
+		kugo.Matching(kugo.PolicyPattern("abc")),
+	)
+	assert.NotNil(t, err)
+}
+
+func Test_MatchesStream(t *testing.T) {
+	t.Parallel()
+	// kupo returns the most recent outputs first
+	var expected []kugo.Match
+	for i := 9; i >= 0; i-- {
+		expected = append(expected, kugo.Match{
+			TransactionID: "abcdef",
+			OutputIndex:   i,
+			Address:       address,
+		})
+	}
+	server := kugotest.New().SetPatterns(address).AddMatches(expected...).HTTP()
+	defer server.Close()
+
+	c := kugo.New(kugo.WithEndpoint(server.URL))
+
+	var matches []kugo.Match
+	for match, err := range c.MatchesStream(context.Background(), kugo.Address(address)) {
+		assert.Nil(t, err)
+		matches = append(matches, match)
+	}
+	assert.Equal(t, expected, matches)
+
+	count := 0
+	for _, err := range c.MatchesStream(context.Background(), kugo.Address(address)) {
+		assert.Nil(t, err)
+		count++
+		if count == 3 {
+			break
+		}
+	}
+	assert.Equal(t, 3, count)
+
+	var errs []error
+	other := kugo.Address(
+		"addr1w8phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gtcyjy7wx",
+	)
+	for _, err := range c.MatchesStream(context.Background(), other) {
+		errs = append(errs, err)
+	}
+	assert.Len(t, errs, 1)
+	assert.True(t, errors.Is(errs[0], kugo.ErrPatternNotIndexed))
+}
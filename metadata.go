@@ -28,7 +28,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -47,7 +46,37 @@ func (c *Client) Metadata(
 	ctx context.Context,
 	slotNo int,
 	txId string,
-) (metadatum []Metadatum, err error) {
+) ([]Metadatum, error) {
+	disk := c.options.diskCache
+	key := strconv.Itoa(slotNo) + "/" + txId
+	if disk != nil {
+		if value, ok := disk.get(diskMetadata, key); ok {
+			var metadata []Metadatum
+			if err := json.Unmarshal(value, &metadata); err == nil {
+				return metadata, nil
+			}
+		}
+	}
+
+	metadata, checkpoint, err := c.fetchMetadata(ctx, slotNo, txId)
+	// Metadata may yet be rolled back, or kupo may not have reached the
+	// slot, until the slot is settled
+	if err == nil && disk != nil && len(metadata) > 0 &&
+		slotNo >= 0 && disk.isStable(uint64(slotNo), checkpoint) {
+		if value, err := json.Marshal(metadata); err == nil {
+			c.storeOnDisk(diskMetadata, key, value)
+		}
+	}
+	return metadata, err
+}
+
+// fetchMetadata fetches metadata from kupo, along with the most recent
+// checkpoint kupo reported, or 0 if it didn't
+func (c *Client) fetchMetadata(
+	ctx context.Context,
+	slotNo int,
+	txId string,
+) (metadatum []Metadatum, checkpoint uint64, err error) {
 	start := time.Now()
 	defer func() {
 		errStr := ""
@@ -66,7 +95,7 @@ func (c *Client) Metadata(
 
 	url, err := url.Parse(c.options.endpoint)
 	if err != nil {
-		return nil, fmt.Errorf(
+		return nil, 0, fmt.Errorf(
 			"unable to parse endpoint %v: %w",
 			c.options.endpoint,
 			err,
@@ -75,36 +104,34 @@ func (c *Client) Metadata(
 	url.Path = "/v1/metadata/" + strconv.Itoa(slotNo)
 
 	if txId != "" {
-		url.Path += "?transaction_id=" + txId
+		url.RawQuery = "transaction_id=" + txId
 	}
 
 	req, err := http.NewRequest(http.MethodGet, url.String(), nil)
 	if err != nil {
-		return nil, fmt.Errorf("unable to build request: %w", err)
+		return nil, 0, fmt.Errorf("unable to build request: %w", err)
 	}
 
-	req.Close = true
 	req = req.WithContext(ctx)
 
-	client := &http.Client{
-		Timeout: c.options.timeout,
-	}
-	resp, err := client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		return nil, fmt.Errorf("unable to fetch metadata: %w", err)
+		return nil, 0, fmt.Errorf("unable to fetch metadata: %w", err)
 	}
 	if resp == nil {
-		return nil, errors.New("failed with a nil response")
+		return nil, 0, errors.New("failed with a nil response")
 	}
 	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+	body, err := readResponse(resp)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %w", err)
+		return nil, 0, err
 	}
 
+	checkpoint, _ = strconv.ParseUint(resp.Header.Get("X-Most-Recent-Checkpoint"), 10, 64)
+
 	response := []Metadatum{}
 	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("unable to parse body %v: %w", string(body), err)
+		return nil, 0, fmt.Errorf("unable to parse body %v: %w", string(body), err)
 	}
-	return response, nil
+	return response, checkpoint, nil
 }
@@ -21,13 +21,17 @@
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
 // SOFTWARE.
 
-package kugo
+package kugo_test
 
 import (
 	"context"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/SundaeSwap-finance/kugo"
+	"github.com/SundaeSwap-finance/kugo/kugotest"
 	"github.com/tj/assert"
 )
 
@@ -37,28 +41,22 @@ func TestClient_Metadata(t *testing.T) {
 		func(t *testing.T) {
 			t.Parallel()
 
-			m := Metadatum{
+			m := kugo.Metadatum{
 				Hash:   "b64602eebf602e8bbce198e2a1d6bbb2a109ae87fa5316135d217110d6d94649",
 				Raw:    "a11902a2a1636d736781781c4d696e737761703a205377617020457861637420496e204f72646572",
 				Schema: json.RawMessage(`{"exampleKey":"exampleValue"}`),
 			}
-			server := NewMockServer().AddMetadata(
-				MetadatumEntry{
-					Slot:      108923398,
-					Tx:        "tx1",
-					Metadatum: m,
-				},
-			).HTTP()
+			server := kugotest.New().AddMetadata(108923398, "tx1", m).HTTP()
 			defer server.Close()
 
-			client := New(WithEndpoint(server.URL))
+			client := kugo.New(kugo.WithEndpoint(server.URL))
 			metadataResponse, err := client.Metadata(
 				context.Background(),
 				108923398,
 				"",
 			)
 			assert.Nil(t, err)
-			expectedList := []Metadatum{m}
+			expectedList := []kugo.Metadatum{m}
 
 			assert.EqualValues(t, expectedList, metadataResponse)
 		},
@@ -69,10 +67,10 @@ func TestClient_Metadata(t *testing.T) {
 		func(t *testing.T) {
 			t.Parallel()
 
-			server := NewMockServer().HTTP()
+			server := kugotest.New().HTTP()
 			defer server.Close()
 
-			client := New(WithEndpoint(server.URL))
+			client := kugo.New(kugo.WithEndpoint(server.URL))
 			metadataResponse, err := client.Metadata(
 				context.Background(),
 				108923398,
@@ -86,4 +84,28 @@ func TestClient_Metadata(t *testing.T) {
 			}
 		},
 	)
+
+	t.Run(
+		"Transaction ID is sent as a query parameter",
+		func(t *testing.T) {
+			t.Parallel()
+
+			// It was once appended to the path, where the "?" was escaped
+			// and kupo answered 404
+			var path, query string
+			server := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					path, query = r.URL.Path, r.URL.RawQuery
+					_, _ = w.Write([]byte("[]"))
+				},
+			))
+			defer server.Close()
+
+			client := kugo.New(kugo.WithEndpoint(server.URL))
+			_, err := client.Metadata(context.Background(), 108923398, "tx1")
+			assert.Nil(t, err)
+			assert.Equal(t, "/v1/metadata/108923398", path)
+			assert.Equal(t, "transaction_id=tx1", query)
+		},
+	)
 }
@@ -0,0 +1,90 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"context"
+
+	"github.com/SundaeSwap-finance/kugo"
+)
+
+// Cache stores values by key; it is the same interface the client's own
+// caches use, so a kugo.LRUCache can back Caching
+type Cache = kugo.Cache
+
+// NewMemoryCache returns an in-memory Cache holding the 1024 most recently
+// used values; use kugo.NewLRUCache for other limits
+func NewMemoryCache() Cache {
+	return kugo.NewLRUCache(kugo.CacheLimits{})
+}
+
+// Caching serves datums and scripts from cache; both are addressed by their
+// hash, so they never change. Other calls, whose answers move with the
+// chain, pass through, as do lookups kupo has no answer for, since the
+// datum or script may yet appear. If cache is nil, a NewMemoryCache is
+// used.
+func Caching(cache Cache) Middleware {
+	if cache == nil {
+		cache = NewMemoryCache()
+	}
+	return func(next kugo.API) kugo.API {
+		return &caching{API: next, cache: cache}
+	}
+}
+
+type caching struct {
+	kugo.API
+	cache Cache
+}
+
+func (c *caching) Datum(ctx context.Context, datumHash string) (string, error) {
+	key := "datum:" + datumHash
+	if value, ok := c.cache.Get(key); ok {
+		if datum, ok := value.(string); ok {
+			return datum, nil
+		}
+	}
+	datum, err := c.API.Datum(ctx, datumHash)
+	if err == nil && datum != "" {
+		c.cache.Set(key, datum)
+	}
+	return datum, err
+}
+
+func (c *caching) Script(
+	ctx context.Context,
+	scriptHash string,
+) (*kugo.Script, error) {
+	key := "script:" + scriptHash
+	if value, ok := c.cache.Get(key); ok {
+		if script, ok := value.(kugo.Script); ok {
+			return &script, nil
+		}
+	}
+	script, err := c.API.Script(ctx, scriptHash)
+	if err == nil && script != nil && script.Script != "" {
+		c.cache.Set(key, *script)
+	}
+	return script, err
+}
@@ -0,0 +1,199 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package middleware provides composable decorators for kugo.API: logging,
+// metrics and caching.
+//
+//	var api kugo.API = kugo.New(kugo.WithEndpoint(endpoint))
+//	api = middleware.Chain(api,
+//		middleware.Logging(logger),
+//		middleware.Metrics(recorder),
+//		middleware.Caching(middleware.NewMemoryCache()),
+//	)
+package middleware
+
+import (
+	"context"
+	"iter"
+	"time"
+
+	"github.com/SundaeSwap-finance/kugo"
+)
+
+// Middleware decorates an API
+type Middleware func(kugo.API) kugo.API
+
+// Chain applies the middlewares to api; the first middleware is outermost,
+// seeing each call first
+func Chain(api kugo.API, middlewares ...Middleware) kugo.API {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		api = middlewares[i](api)
+	}
+	return api
+}
+
+// ObserverFunc is told about every call made through Observe, once it
+// completes; method is the name of the API method
+type ObserverFunc func(
+	ctx context.Context,
+	method string,
+	duration time.Duration,
+	err error,
+)
+
+// Observe calls fn after every API call, with its duration and error; it is
+// the building block of Logging and Metrics
+func Observe(fn ObserverFunc) Middleware {
+	return func(next kugo.API) kugo.API {
+		return &observed{next: next, observe: fn}
+	}
+}
+
+type observed struct {
+	next    kugo.API
+	observe ObserverFunc
+}
+
+func (o *observed) done(
+	ctx context.Context,
+	method string,
+	start time.Time,
+	err error,
+) {
+	o.observe(ctx, method, time.Since(start), err)
+}
+
+func (o *observed) Matches(
+	ctx context.Context,
+	filters ...kugo.MatchesFilter,
+) (matches []kugo.Match, err error) {
+	defer func(start time.Time) { o.done(ctx, "Matches", start, err) }(
+		time.Now(),
+	)
+	return o.next.Matches(ctx, filters...)
+}
+
+func (o *observed) MatchesStream(
+	ctx context.Context,
+	filters ...kugo.MatchesFilter,
+) iter.Seq2[kugo.Match, error] {
+	return func(yield func(kugo.Match, error) bool) {
+		var err error
+		start := time.Now()
+		defer func() { o.done(ctx, "MatchesStream", start, err) }()
+		for match, matchErr := range o.next.MatchesStream(ctx, filters...) {
+			err = matchErr
+			if !yield(match, matchErr) {
+				return
+			}
+		}
+	}
+}
+
+func (o *observed) Patterns(
+	ctx context.Context,
+) (patterns []string, err error) {
+	defer func(start time.Time) { o.done(ctx, "Patterns", start, err) }(
+		time.Now(),
+	)
+	return o.next.Patterns(ctx)
+}
+
+func (o *observed) AddPattern(
+	ctx context.Context,
+	pattern kugo.PatternSpec,
+	opts ...kugo.PatternOption,
+) (patterns []string, err error) {
+	defer func(start time.Time) { o.done(ctx, "AddPattern", start, err) }(
+		time.Now(),
+	)
+	return o.next.AddPattern(ctx, pattern, opts...)
+}
+
+func (o *observed) RemovePattern(
+	ctx context.Context,
+	pattern kugo.PatternSpec,
+) (deleted int, err error) {
+	defer func(start time.Time) { o.done(ctx, "RemovePattern", start, err) }(
+		time.Now(),
+	)
+	return o.next.RemovePattern(ctx, pattern)
+}
+
+func (o *observed) PatternIncluded(
+	ctx context.Context,
+	pattern kugo.PatternSpec,
+) (included bool, err error) {
+	defer func(start time.Time) { o.done(ctx, "PatternIncluded", start, err) }(
+		time.Now(),
+	)
+	return o.next.PatternIncluded(ctx, pattern)
+}
+
+func (o *observed) Checkpoints(
+	ctx context.Context,
+	filters ...kugo.CheckpointsFilter,
+) (points []kugo.Point, err error) {
+	defer func(start time.Time) { o.done(ctx, "Checkpoints", start, err) }(
+		time.Now(),
+	)
+	return o.next.Checkpoints(ctx, filters...)
+}
+
+func (o *observed) Datum(
+	ctx context.Context,
+	datumHash string,
+) (datum string, err error) {
+	defer func(start time.Time) { o.done(ctx, "Datum", start, err) }(time.Now())
+	return o.next.Datum(ctx, datumHash)
+}
+
+func (o *observed) Script(
+	ctx context.Context,
+	scriptHash string,
+) (script *kugo.Script, err error) {
+	defer func(start time.Time) { o.done(ctx, "Script", start, err) }(
+		time.Now(),
+	)
+	return o.next.Script(ctx, scriptHash)
+}
+
+func (o *observed) Metadata(
+	ctx context.Context,
+	slotNo int,
+	txId string,
+) (metadata []kugo.Metadatum, err error) {
+	defer func(start time.Time) { o.done(ctx, "Metadata", start, err) }(
+		time.Now(),
+	)
+	return o.next.Metadata(ctx, slotNo, txId)
+}
+
+func (o *observed) Health(
+	ctx context.Context,
+) (health *kugo.Health, err error) {
+	defer func(start time.Time) { o.done(ctx, "Health", start, err) }(
+		time.Now(),
+	)
+	return o.next.Health(ctx)
+}
@@ -0,0 +1,285 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SundaeSwap-finance/kugo"
+	"github.com/SundaeSwap-finance/kugo/kugotest"
+	"github.com/SundaeSwap-finance/kugo/middleware"
+	"github.com/SundaeSwap-finance/ogmigo/v6"
+	"github.com/tj/assert"
+)
+
+const (
+	unitDatum     = "d87980"
+	unitDatumHash = "923918e403bf43c34b4ef6b48eb2ee04babed17320d8d1b9ff9ad086e86f44ec"
+)
+
+type countingTransport struct {
+	requests atomic.Int64
+}
+
+func (c *countingTransport) RoundTrip(
+	req *http.Request,
+) (*http.Response, error) {
+	c.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+type call struct {
+	Method string
+	Err    error
+}
+
+type recorder struct {
+	mutex sync.Mutex
+	calls []call
+}
+
+func (r *recorder) ObserveCall(
+	method string,
+	duration time.Duration,
+	err error,
+) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.calls = append(r.calls, call{Method: method, Err: err})
+}
+
+type logger struct {
+	mutex    sync.Mutex
+	messages []string
+}
+
+func (l *logger) Debug(
+	message string,
+	kvs ...ogmigo.KeyValue,
+) {
+	l.log("debug: "+message, kvs)
+}
+
+func (l *logger) Info(
+	message string,
+	kvs ...ogmigo.KeyValue,
+) {
+	l.log("info: "+message, kvs)
+}
+func (l *logger) With(kvs ...ogmigo.KeyValue) ogmigo.Logger { return l }
+
+func (l *logger) log(message string, kvs []ogmigo.KeyValue) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, kv := range kvs {
+		if kv.Key == "method" {
+			message += " " + kv.Value
+		}
+	}
+	l.messages = append(l.messages, message)
+}
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	server := kugotest.New().AddDatums(unitDatum).HTTP()
+	defer server.Close()
+	transport := &countingTransport{}
+	client := kugo.New(
+		kugo.WithEndpoint(server.URL),
+		kugo.WithTransport(transport),
+	)
+
+	metrics := &recorder{}
+	logs := &logger{}
+	api := middleware.Chain(client,
+		middleware.Logging(logs),
+		middleware.Metrics(metrics),
+		middleware.Caching(middleware.NewMemoryCache()),
+	)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		datum, err := api.Datum(ctx, unitDatumHash)
+		assert.Nil(t, err)
+		assert.Equal(t, unitDatum, datum)
+	}
+	assert.EqualValues(t, 1, transport.requests.Load())
+
+	// Unknown datums aren't cached
+	for i := 0; i < 2; i++ {
+		datum, err := api.Datum(ctx, "aaaa")
+		assert.Nil(t, err)
+		assert.Equal(t, "", datum)
+	}
+	assert.EqualValues(t, 3, transport.requests.Load())
+
+	_, err := api.Matches(ctx, kugo.Pattern("not-a-pattern"))
+	assert.NotNil(t, err)
+	for match, err := range api.MatchesStream(ctx) {
+		assert.Nil(t, err)
+		_ = match
+	}
+
+	assert.Equal(t, []call{
+		{"Datum", nil}, {"Datum", nil}, {"Datum", nil},
+		{"Datum", nil}, {"Datum", nil},
+		{"Matches", err},
+		{"MatchesStream", nil},
+	}, metrics.calls)
+	assert.Equal(t, "info: kupo call failed Matches", logs.messages[5])
+	assert.Equal(t, "debug: kupo call finished MatchesStream", logs.messages[6])
+}
+
+func TestCaching_Script(t *testing.T) {
+	t.Parallel()
+
+	script := kugo.Script{
+		Language: kugo.ScriptLanguagePlutusV1,
+		Script:   "4d01000033222220051200120011",
+	}
+	server := kugotest.New().AddScripts(script).HTTP()
+	defer server.Close()
+	transport := &countingTransport{}
+	api := middleware.Caching(middleware.NewMemoryCache())(
+		kugo.New(kugo.WithEndpoint(server.URL), kugo.WithTransport(transport)),
+	)
+
+	for i := 0; i < 2; i++ {
+		got, err := api.Script(
+			context.Background(),
+			"67f33146617a5e61936081db3b2117cbf59bd2123748f58ac9678656",
+		)
+		assert.Nil(t, err)
+		assert.Equal(t, script, *got)
+		// Callers can't corrupt the cache through the returned pointer
+		got.Script = ""
+	}
+	assert.EqualValues(t, 1, transport.requests.Load())
+
+	// Everything else passes straight through
+	_, err := api.Patterns(context.Background())
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, transport.requests.Load())
+}
+
+func TestObserve_Errors(t *testing.T) {
+	t.Parallel()
+
+	var observed []error
+	api := middleware.Observe(
+		func(_ context.Context, method string, _ time.Duration, err error) {
+			observed = append(observed, err)
+		},
+	)(
+		kugo.New(
+			kugo.WithEndpoint("http://127.0.0.1:1"),
+			kugo.WithLogger(ogmigo.NopLogger),
+		),
+	)
+
+	_, err := api.Health(context.Background())
+	assert.NotNil(t, err)
+	assert.Len(t, observed, 1)
+	assert.Equal(t, err, observed[0])
+}
+
+func TestHelpersOverMiddleware(t *testing.T) {
+	t.Parallel()
+
+	server := kugotest.New().
+		AddDatums(unitDatum).
+		RollForward(
+			kugo.Point{SlotNo: 10, HeaderHash: "aa"},
+			kugo.Match{TransactionID: "tx1", Address: "addr1"},
+		).
+		HTTP()
+	defer server.Close()
+	transport := &countingTransport{}
+	metrics := &recorder{}
+	api := middleware.Chain(
+		kugo.New(kugo.WithEndpoint(server.URL), kugo.WithTransport(transport)),
+		middleware.Metrics(metrics),
+		middleware.Caching(middleware.NewMemoryCache()),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	matches := []kugo.Match{
+		{DatumHash: unitDatumHash},
+		{DatumHash: unitDatumHash},
+	}
+	assert.Nil(t, kugo.ResolveDatums(ctx, api, matches, 0))
+	assert.Equal(t, unitDatum, matches[1].Datum)
+
+	var unit struct {
+		_ struct{} `plutus:"constr=0"`
+	}
+	assert.Nil(t, kugo.DatumInto(ctx, api, unitDatumHash, &unit))
+	assert.EqualValues(t, 1, transport.requests.Load())
+
+	for event, err := range kugo.Watch(
+		ctx,
+		api,
+		[]kugo.MatchesFilter{kugo.CreatedAfter(5)},
+		kugo.PollInterval(time.Millisecond),
+	) {
+		assert.Nil(t, err)
+		assert.Equal(t, kugo.UtxoCreated, event.Type)
+		assert.Equal(t, "tx1", event.Match.TransactionID)
+		break
+	}
+
+	specs, err := kugo.PatternSpecs(ctx, api)
+	assert.Nil(t, err)
+	assert.Equal(t, []kugo.PatternSpec{kugo.AnyPattern()}, specs)
+
+	assert.Nil(
+		t,
+		kugo.WaitUntilSynced(ctx, api, 0, kugo.PollInterval(time.Millisecond)),
+	)
+	assert.Equal(t, "Health", metrics.calls[len(metrics.calls)-1].Method)
+}
+
+func TestNewMemoryCache_Bounded(t *testing.T) {
+	t.Parallel()
+
+	cache := middleware.NewMemoryCache()
+	for i := 0; i < 2000; i++ {
+		cache.Set(fmt.Sprintf("datum:%v", i), unitDatum)
+	}
+	_, ok := cache.Get("datum:0")
+	assert.False(t, ok)
+	_, ok = cache.Get("datum:1999")
+	assert.True(t, ok)
+	assert.Equal(t, 1024, cache.(*kugo.LRUCache).Len())
+}
@@ -0,0 +1,79 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/SundaeSwap-finance/ogmigo/v6"
+)
+
+// Logging logs every call with its duration and error, failures at info
+// level and successes at debug level
+func Logging(logger ogmigo.Logger) Middleware {
+	return Observe(
+		func(
+			_ context.Context,
+			method string,
+			duration time.Duration,
+			err error,
+		) {
+			kvs := []ogmigo.KeyValue{
+				ogmigo.KV("method", method),
+				ogmigo.KV(
+					"duration",
+					duration.Round(time.Millisecond).String(),
+				),
+			}
+			if err != nil {
+				logger.Info(
+					"kupo call failed",
+					append(kvs, ogmigo.KV("err", err.Error()))...)
+				return
+			}
+			logger.Debug("kupo call finished", kvs...)
+		},
+	)
+}
+
+// MetricsRecorder receives a measurement for each call; implement it to
+// feed prometheus, statsd or similar
+type MetricsRecorder interface {
+	ObserveCall(method string, duration time.Duration, err error)
+}
+
+// Metrics reports every call to the recorder
+func Metrics(recorder MetricsRecorder) Middleware {
+	return Observe(
+		func(
+			_ context.Context,
+			method string,
+			duration time.Duration,
+			err error,
+		) {
+			recorder.ObserveCall(method, duration, err)
+		},
+	)
+}
@@ -0,0 +1,347 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/SundaeSwap-finance/kugo/plutusdata"
+)
+
+type NativeScriptKind int
+
+const (
+	NativeScriptKindUnknown NativeScriptKind = iota
+	// NativeScriptKindSig requires a signature from KeyHash
+	NativeScriptKindSig
+	// NativeScriptKindAll requires every sub-script
+	NativeScriptKindAll
+	// NativeScriptKindAny requires at least one sub-script
+	NativeScriptKindAny
+	// NativeScriptKindAtLeast requires Required of the sub-scripts
+	NativeScriptKindAtLeast
+	// NativeScriptKindAfter requires the validity interval to start at or
+	// after Slot
+	NativeScriptKindAfter
+	// NativeScriptKindBefore requires the validity interval to end at or
+	// before Slot
+	NativeScriptKindBefore
+)
+
+// String returns the name cardano-cli uses for the kind
+func (k NativeScriptKind) String() string {
+	switch k {
+	case NativeScriptKindSig:
+		return "sig"
+	case NativeScriptKindAll:
+		return "all"
+	case NativeScriptKindAny:
+		return "any"
+	case NativeScriptKindAtLeast:
+		return "atLeast"
+	case NativeScriptKindAfter:
+		return "after"
+	case NativeScriptKindBefore:
+		return "before"
+	default:
+		return "unknown"
+	}
+}
+
+// NativeScript is a parsed native (timelock/multisig) script
+type NativeScript struct {
+	Kind     NativeScriptKind
+	KeyHash  string // hex encoded, for sig
+	Required int    // for atLeast
+	Slot     uint64 // for after and before
+	Scripts  []NativeScript
+}
+
+// ValidityInterval of a transaction; nil bounds are unbounded
+type ValidityInterval struct {
+	InvalidBefore    *uint64
+	InvalidHereafter *uint64
+}
+
+// Native parses the script, which must be a native script
+func (s Script) Native() (NativeScript, error) {
+	if s.Language != ScriptLanguageNative {
+		return NativeScript{}, errors.New("script is not a native script")
+	}
+	return ParseNativeScript(s.Script)
+}
+
+// ParseNativeScript parses a hex encoded, CBOR serialized native script
+func ParseNativeScript(s string) (NativeScript, error) {
+	// Native scripts only use arrays, unsigned integers and byte strings,
+	// all of which the plutus data decoder understands
+	node, err := plutusdata.DecodeHex(s)
+	if err != nil {
+		return NativeScript{}, fmt.Errorf("invalid native script: %w", err)
+	}
+	script, err := nativeScriptFromData(node)
+	if err != nil {
+		return NativeScript{}, fmt.Errorf("invalid native script: %w", err)
+	}
+	return script, nil
+}
+
+func nativeScriptFromData(node plutusdata.Data) (NativeScript, error) {
+	list, ok := node.(plutusdata.List)
+	if !ok || len(list.Items) == 0 {
+		return NativeScript{}, errors.New("expected a non-empty array")
+	}
+	tag, err := nativeUint(list.Items[0])
+	if err != nil {
+		return NativeScript{}, err
+	}
+	args := list.Items[1:]
+
+	arity := map[uint64]int{0: 1, 1: 1, 2: 1, 3: 2, 4: 1, 5: 1}
+	if n, ok := arity[tag]; !ok {
+		return NativeScript{}, fmt.Errorf("unknown native script type %v", tag)
+	} else if len(args) != n {
+		return NativeScript{}, fmt.Errorf(
+			"native script type %v expects %v arguments, got %v",
+			tag,
+			n,
+			len(args),
+		)
+	}
+
+	switch tag {
+	case 0:
+		keyHash, ok := args[0].(plutusdata.Bytes)
+		if !ok || len(keyHash.Value) != 28 {
+			return NativeScript{}, errors.New("expected a 28 byte key hash")
+		}
+		return NativeScript{
+			Kind:    NativeScriptKindSig,
+			KeyHash: hex.EncodeToString(keyHash.Value),
+		}, nil
+
+	case 1, 2:
+		scripts, err := nativeScriptList(args[0])
+		if err != nil {
+			return NativeScript{}, err
+		}
+		kind := NativeScriptKindAll
+		if tag == 2 {
+			kind = NativeScriptKindAny
+		}
+		return NativeScript{Kind: kind, Scripts: scripts}, nil
+
+	case 3:
+		required, err := nativeUint(args[0])
+		if err != nil {
+			return NativeScript{}, err
+		}
+		if required > math.MaxInt {
+			return NativeScript{}, fmt.Errorf(
+				"required %v is out of range",
+				required,
+			)
+		}
+		scripts, err := nativeScriptList(args[1])
+		if err != nil {
+			return NativeScript{}, err
+		}
+		return NativeScript{
+			Kind:     NativeScriptKindAtLeast,
+			Required: int(required),
+			Scripts:  scripts,
+		}, nil
+
+	default:
+		slot, err := nativeUint(args[0])
+		if err != nil {
+			return NativeScript{}, err
+		}
+		kind := NativeScriptKindAfter
+		if tag == 5 {
+			kind = NativeScriptKindBefore
+		}
+		return NativeScript{Kind: kind, Slot: slot}, nil
+	}
+}
+
+func nativeScriptList(node plutusdata.Data) ([]NativeScript, error) {
+	list, ok := node.(plutusdata.List)
+	if !ok {
+		return nil, errors.New("expected an array of scripts")
+	}
+	scripts := make([]NativeScript, 0, len(list.Items))
+	for _, item := range list.Items {
+		script, err := nativeScriptFromData(item)
+		if err != nil {
+			return nil, err
+		}
+		scripts = append(scripts, script)
+	}
+	return scripts, nil
+}
+
+func nativeUint(node plutusdata.Data) (uint64, error) {
+	n, ok := node.(plutusdata.Integer)
+	if !ok || n.Value == nil || n.Value.Sign() < 0 || !n.Value.IsUint64() {
+		return 0, errors.New("expected an unsigned integer")
+	}
+	return n.Value.Uint64(), nil
+}
+
+// Evaluate reports whether the script is satisfied by a transaction signed
+// by the given hex encoded key hashes, with the given validity interval;
+// this follows the ledger's rules, so e.g. after requires a lower bound
+func (n NativeScript) Evaluate(
+	keyHashes []string,
+	interval ValidityInterval,
+) bool {
+	signers := make(map[string]struct{}, len(keyHashes))
+	for _, keyHash := range keyHashes {
+		signers[strings.ToLower(keyHash)] = struct{}{}
+	}
+	return n.evaluate(signers, interval)
+}
+
+func (n NativeScript) evaluate(
+	signers map[string]struct{},
+	interval ValidityInterval,
+) bool {
+	switch n.Kind {
+	case NativeScriptKindSig:
+		_, ok := signers[strings.ToLower(n.KeyHash)]
+		return ok
+	case NativeScriptKindAll:
+		for _, script := range n.Scripts {
+			if !script.evaluate(signers, interval) {
+				return false
+			}
+		}
+		return true
+	case NativeScriptKindAny:
+		for _, script := range n.Scripts {
+			if script.evaluate(signers, interval) {
+				return true
+			}
+		}
+		return false
+	case NativeScriptKindAtLeast:
+		satisfied := 0
+		for _, script := range n.Scripts {
+			if script.evaluate(signers, interval) {
+				satisfied++
+			}
+		}
+		return satisfied >= n.Required
+	case NativeScriptKindAfter:
+		return interval.InvalidBefore != nil &&
+			n.Slot <= *interval.InvalidBefore
+	case NativeScriptKindBefore:
+		return interval.InvalidHereafter != nil &&
+			*interval.InvalidHereafter <= n.Slot
+	default:
+		return false
+	}
+}
+
+// nativeScriptJSON is the format used by cardano-cli
+type nativeScriptJSON struct {
+	Type     string         `json:"type"`
+	KeyHash  string         `json:"keyHash,omitempty"`
+	Required *int           `json:"required,omitempty"`
+	Slot     *uint64        `json:"slot,omitempty"`
+	Scripts  []NativeScript `json:"scripts,omitempty"`
+}
+
+// MarshalJSON encodes the script in cardano-cli's JSON format
+func (n NativeScript) MarshalJSON() ([]byte, error) {
+	r := nativeScriptJSON{Type: n.Kind.String()}
+	switch n.Kind {
+	case NativeScriptKindSig:
+		r.KeyHash = n.KeyHash
+	case NativeScriptKindAll, NativeScriptKindAny:
+		r.Scripts = n.Scripts
+		if r.Scripts == nil {
+			r.Scripts = []NativeScript{}
+		}
+	case NativeScriptKindAtLeast:
+		r.Required = &n.Required
+		r.Scripts = n.Scripts
+	case NativeScriptKindAfter, NativeScriptKindBefore:
+		r.Slot = &n.Slot
+	default:
+		return nil, fmt.Errorf(
+			"unable to encode native script of kind %v",
+			n.Kind,
+		)
+	}
+	return json.Marshal(r)
+}
+
+// UnmarshalJSON decodes a script in cardano-cli's JSON format
+func (n *NativeScript) UnmarshalJSON(data []byte) error {
+	var r nativeScriptJSON
+	if err := json.Unmarshal(data, &r); err != nil {
+		return err
+	}
+	script := NativeScript{KeyHash: r.KeyHash, Scripts: r.Scripts}
+	switch r.Type {
+	case "sig":
+		script.Kind = NativeScriptKindSig
+		if !isHex(r.KeyHash, 56) {
+			return fmt.Errorf("invalid key hash '%v'", r.KeyHash)
+		}
+	case "all":
+		script.Kind = NativeScriptKindAll
+	case "any":
+		script.Kind = NativeScriptKindAny
+	case "atLeast":
+		script.Kind = NativeScriptKindAtLeast
+		if r.Required == nil {
+			return errors.New("atLeast script is missing required")
+		}
+		if *r.Required < 0 {
+			return fmt.Errorf("invalid required %v", *r.Required)
+		}
+		script.Required = *r.Required
+	case "after", "before":
+		script.Kind = NativeScriptKindAfter
+		if r.Type == "before" {
+			script.Kind = NativeScriptKindBefore
+		}
+		if r.Slot == nil {
+			return fmt.Errorf("%v script is missing slot", r.Type)
+		}
+		script.Slot = *r.Slot
+	default:
+		return fmt.Errorf("unknown native script type: '%v'", r.Type)
+	}
+	*n = script
+	return nil
+}
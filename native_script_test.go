@@ -0,0 +1,170 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestParseNativeScript(t *testing.T) {
+	script, err := Script{
+		Language: ScriptLanguageNative,
+		Script:   "8201838200581c3c07030e36bfffe67e2e2ec09e5293d384637cd2f004356ef320f3fe8204186482051896",
+	}.Native()
+	assert.Nil(t, err)
+	assert.Equal(t, NativeScript{
+		Kind: NativeScriptKindAll,
+		Scripts: []NativeScript{
+			{
+				Kind:    NativeScriptKindSig,
+				KeyHash: "3c07030e36bfffe67e2e2ec09e5293d384637cd2f004356ef320f3fe",
+			},
+			{Kind: NativeScriptKindAfter, Slot: 100},
+			{Kind: NativeScriptKindBefore, Slot: 150},
+		},
+	}, script)
+
+	data, err := json.Marshal(script)
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		`{"type":"all","scripts":[{"type":"sig","keyHash":"3c07030e36bfffe67e2e2ec09e5293d384637cd2f004356ef320f3fe"},{"type":"after","slot":100},{"type":"before","slot":150}]}`,
+		string(data),
+	)
+
+	var parsed NativeScript
+	assert.Nil(t, json.Unmarshal(data, &parsed))
+	assert.Equal(t, script, parsed)
+
+	_, err = Script{
+		Language: ScriptLanguagePlutusV2,
+		Script:   "4e4d01000033222220051200120011",
+	}.Native()
+	assert.NotNil(t, err)
+
+	invalid := []string{
+		"",
+		"80",
+		"8206",
+		"82004100",
+		"830102",
+		"83030183",
+		"8201a0",
+	}
+	for _, invalid := range invalid {
+		_, err = ParseNativeScript(invalid)
+		assert.NotNil(t, err, invalid)
+	}
+}
+
+func TestParseNativeScript_Required(t *testing.T) {
+	// atLeast 2^63 of no scripts, which would wrap to a negative int and
+	// be satisfied by anything
+	_, err := ParseNativeScript("83031b800000000000000080")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "out of range")
+
+	script, err := ParseNativeScript("83030180")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, script.Required)
+	assert.False(t, script.Evaluate(nil, ValidityInterval{}))
+
+	var parsed NativeScript
+	err = json.Unmarshal(
+		[]byte(`{"type":"atLeast","required":-1,"scripts":[]}`),
+		&parsed,
+	)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid required")
+}
+
+func TestNativeScript_Evaluate(t *testing.T) {
+	a := strings.Repeat("aa", 28)
+	b := strings.Repeat("bb", 28)
+	c := strings.Repeat("cc", 28)
+	var treasury NativeScript
+	err := json.Unmarshal([]byte(`{"type":"all","scripts":[
+		{"type":"atLeast","required":2,"scripts":[
+			{"type":"sig","keyHash":"`+a+`"},
+			{"type":"sig","keyHash":"`+b+`"},
+			{"type":"sig","keyHash":"`+c+`"}
+		]},
+		{"type":"any","scripts":[{"type":"after","slot":100},{"type":"before","slot":50}]}
+	]}`), &treasury)
+	assert.Nil(t, err)
+
+	slot := func(s uint64) *uint64 { return &s }
+	testCases := map[string]struct {
+		Signers  []string
+		Interval ValidityInterval
+		Want     bool
+	}{
+		"two signers after lock": {
+			[]string{a, c},
+			ValidityInterval{InvalidBefore: slot(100)},
+			true,
+		},
+		"upper case key hashes": {
+			[]string{strings.ToUpper(a), b},
+			ValidityInterval{InvalidBefore: slot(120)},
+			true,
+		},
+		"one signer": {
+			[]string{a},
+			ValidityInterval{InvalidBefore: slot(100)},
+			false,
+		},
+		"before lock": {
+			[]string{a, b},
+			ValidityInterval{InvalidBefore: slot(99)},
+			false,
+		},
+		"unbounded interval": {
+			[]string{a, b},
+			ValidityInterval{},
+			false,
+		},
+		"expires before deadline": {
+			[]string{b, c},
+			ValidityInterval{InvalidHereafter: slot(50)},
+			true,
+		},
+		"expires after the deadline": {
+			[]string{b, c},
+			ValidityInterval{InvalidHereafter: slot(51)},
+			false,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.Want, treasury.Evaluate(tc.Signers, tc.Interval))
+		})
+	}
+}
@@ -24,6 +24,7 @@
 package kugo
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/SundaeSwap-finance/ogmigo/v6"
@@ -31,9 +32,19 @@ import (
 
 // Options available to kugo client
 type Options struct {
-	endpoint string
-	timeout  time.Duration
-	logger   ogmigo.Logger
+	endpoint   string
+	timeout    time.Duration
+	logger     ogmigo.Logger
+	httpClient *http.Client
+	transport  http.RoundTripper
+	retry      *RetryPolicy
+	poll       time.Duration
+
+	verifyDatums bool
+	recordDir    string
+	datumCache   *CacheLimits
+	scriptCache  *CacheLimits
+	diskCache    *DiskCache
 }
 
 // Option to kugo client
@@ -67,9 +78,92 @@ func WithLogger(logger ogmigo.Logger) Option {
 	}
 }
 
+// WithHTTPClient uses the provided http client for all requests; the timeout
+// and transport options are ignored in favor of the client's own settings
+func WithHTTPClient(client *http.Client) Option {
+	return func(opts *Options) {
+		opts.httpClient = client
+	}
+}
+
+// WithTransport uses the provided transport for all requests, allowing
+// connection pooling to be tuned; defaults to a keep-alive transport cloned
+// from http.DefaultTransport
+func WithTransport(transport http.RoundTripper) Option {
+	return func(opts *Options) {
+		opts.transport = transport
+	}
+}
+
+// WithRetry retries idempotent requests that fail with a transport error,
+// or that kupo answers with 429, 502, 503 or 504, backing off between
+// attempts as described by the policy and honoring any Retry-After header
+func WithRetry(policy RetryPolicy) Option {
+	return func(opts *Options) {
+		p := policy.withDefaults()
+		opts.retry = &p
+	}
+}
+
+// WithPollInterval sets how often helpers that wait on kupo, such as
+// WaitUntilSynced, poll it; defaults to 1 second
+func WithPollInterval(interval time.Duration) Option {
+	return func(opts *Options) {
+		opts.poll = interval
+	}
+}
+
+// WithDatumVerification checks that every datum fetched by the client hashes
+// to the requested hash, returning an *IntegrityError when it doesn't
+func WithDatumVerification() Option {
+	return func(opts *Options) {
+		opts.verifyDatums = true
+	}
+}
+
+// WithRecorder saves every request the client sends, and the response kupo
+// sends back, as fixtures in dir; serve them offline with NewReplayTransport.
+// Recording a request replaces the fixtures an earlier run left for it.
+func WithRecorder(dir string) Option {
+	return func(opts *Options) {
+		opts.recordDir = dir
+	}
+}
+
+// WithDatumCache keeps fetched datums in memory, up to the given limits;
+// datums never change for a given hash, so they need no expiry. Concurrent
+// lookups of the same datum share a single request to kupo.
+func WithDatumCache(limits CacheLimits) Option {
+	return func(opts *Options) {
+		opts.datumCache = &limits
+	}
+}
+
+// WithScriptCache keeps fetched scripts in memory, as WithDatumCache does
+// for datums
+func WithScriptCache(limits CacheLimits) Option {
+	return func(opts *Options) {
+		opts.scriptCache = &limits
+	}
+}
+
+// WithDiskCache saves fetched datums, scripts and the metadata of settled
+// slots in cache, so they survive restarts; on a miss in memory, the disk
+// cache is consulted before kupo. The caller remains responsible for
+// closing the cache.
+func WithDiskCache(cache *DiskCache) Option {
+	return func(opts *Options) {
+		opts.diskCache = cache
+	}
+}
+
+// defaultTimeout bounds requests, unless changed with WithTimeout
+const defaultTimeout = 5 * time.Minute
+
 func buildOptions(opts ...Option) Options {
 	var options Options
-	options.timeout = 5 * time.Minute
+	options.timeout = defaultTimeout
+	options.poll = time.Second
 	for _, opt := range opts {
 		opt(&options)
 	}
@@ -79,5 +173,73 @@ func buildOptions(opts ...Option) Options {
 	if options.logger == nil {
 		options.logger = ogmigo.DefaultLogger
 	}
+	if options.transport == nil {
+		options.transport = defaultTransport()
+	}
+	if options.httpClient == nil {
+		options.httpClient = &http.Client{
+			Timeout:   options.timeout,
+			Transport: options.transport,
+		}
+	}
+	if options.recordDir != "" {
+		client := *options.httpClient
+		client.Transport = newRecordingTransport(options.recordDir, client.Transport)
+		options.httpClient = &client
+	}
 	return options
 }
+
+// defaultTransport keeps connections to kupo alive between requests; since
+// all requests go to a single host, allow many more idle connections to it
+// than the standard library's default of 2
+func defaultTransport() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 100
+	transport.MaxIdleConnsPerHost = 100
+	return transport
+}
+
+// PollOption configures the helpers that poll any API, such as
+// WaitUntilSynced and ChainTracker; a Client's helpers are configured with
+// WithPollInterval and WithLogger instead
+type PollOption func(*pollOptions)
+
+type pollOptions struct {
+	interval time.Duration
+	logger   ogmigo.Logger
+}
+
+// PollInterval sets how often kupo is polled; defaults to 1 second
+func PollInterval(interval time.Duration) PollOption {
+	return func(opts *pollOptions) {
+		opts.interval = interval
+	}
+}
+
+// PollLogger logs progress and errors while polling; defaults to
+// ogmigo.DefaultLogger
+func PollLogger(logger ogmigo.Logger) PollOption {
+	return func(opts *pollOptions) {
+		opts.logger = logger
+	}
+}
+
+func buildPollOptions(opts ...PollOption) pollOptions {
+	var options pollOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.interval <= 0 {
+		options.interval = time.Second
+	}
+	if options.logger == nil {
+		options.logger = ogmigo.DefaultLogger
+	}
+	return options
+}
+
+// pollOptions configures the helpers a Client runs over itself
+func (c *Client) pollOptions() []PollOption {
+	return []PollOption{PollInterval(c.options.poll), PollLogger(c.logger)}
+}
@@ -0,0 +1,170 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sort"
+)
+
+// MatchesCursor records how far a MatchesPaginator has progressed. It can be
+// persisted (e.g. as JSON) and handed back to ResumeFrom after a restart.
+type MatchesCursor struct {
+	// Slot is the earliest slot that may still contain unseen matches; all
+	// matches created before it have been yielded
+	Slot uint64 `json:"slot"`
+	// Yielded lists the output references (idx@txid) created at Slot that
+	// have already been yielded
+	Yielded []string `json:"yielded,omitempty"`
+}
+
+// MatchesPaginator walks the matches created in a range of slots, issuing
+// one Matches call per window of slots. It is not safe for concurrent use.
+type MatchesPaginator struct {
+	api     API
+	to      uint64
+	window  uint64
+	filters []MatchesFilter
+	cursor  MatchesCursor
+}
+
+// NewMatchesPaginator pages through the matches created in [from, to),
+// window slots at a time; if to is 0, pagination runs up to the most recent
+// checkpoint. The slot filters are managed by the paginator, so any
+// CreatedAfter/CreatedBefore in filters are overridden.
+func NewMatchesPaginator(
+	api API,
+	from, to, window uint64,
+	filters ...MatchesFilter,
+) *MatchesPaginator {
+	if window == 0 {
+		window = 1
+	}
+	return &MatchesPaginator{
+		api:     api,
+		to:      to,
+		window:  window,
+		filters: filters,
+		cursor:  MatchesCursor{Slot: from},
+	}
+}
+
+// ResumeFrom continues pagination from a cursor previously returned by
+// Cursor
+func (p *MatchesPaginator) ResumeFrom(cursor MatchesCursor) *MatchesPaginator {
+	p.cursor = MatchesCursor{
+		Slot:    cursor.Slot,
+		Yielded: append([]string(nil), cursor.Yielded...),
+	}
+	return p
+}
+
+// Cursor returns the current position of the paginator
+func (p *MatchesPaginator) Cursor() MatchesCursor {
+	return MatchesCursor{
+		Slot:    p.cursor.Slot,
+		Yielded: append([]string(nil), p.cursor.Yielded...),
+	}
+}
+
+// All yields matches in slot order, deduplicated by output reference.
+// Iteration stops after the first error; the cursor is left pointing at the
+// first match that was not yielded, so calling All again retries from there.
+func (p *MatchesPaginator) All(ctx context.Context) iter.Seq2[Match, error] {
+	return func(yield func(Match, error) bool) {
+		to := p.to
+		if to == 0 {
+			points, err := p.api.Checkpoints(ctx, Latest())
+			if err != nil {
+				yield(Match{}, fmt.Errorf("unable to find most recent checkpoint: %w", err))
+				return
+			}
+			if len(points) == 0 {
+				return
+			}
+			to = uint64(points[0].SlotNo) + 1
+		}
+
+		for p.cursor.Slot < to {
+			lo := p.cursor.Slot
+			hi := min(lo+p.window, to)
+
+			// kupo's slot filters are exclusive
+			after := uint64(0)
+			if lo > 0 {
+				after = lo - 1
+			}
+			filters := append([]MatchesFilter{}, p.filters...)
+			filters = append(filters, CreatedAfter(after), CreatedBefore(hi))
+			matches, err := p.api.Matches(ctx, filters...)
+			if err != nil {
+				yield(Match{}, fmt.Errorf(
+					"unable to fetch matches for slots [%v, %v): %w",
+					lo,
+					hi,
+					err,
+				))
+				return
+			}
+
+			sort.SliceStable(matches, func(i, j int) bool {
+				a, b := matches[i], matches[j]
+				if a.CreatedAt.SlotNo != b.CreatedAt.SlotNo {
+					return a.CreatedAt.SlotNo < b.CreatedAt.SlotNo
+				}
+				if a.TransactionIndex != b.TransactionIndex {
+					return a.TransactionIndex < b.TransactionIndex
+				}
+				return a.OutputIndex < b.OutputIndex
+			})
+
+			seen := map[string]struct{}{}
+			for _, ref := range p.cursor.Yielded {
+				seen[ref] = struct{}{}
+			}
+			for _, match := range matches {
+				slot := uint64(match.CreatedAt.SlotNo)
+				if slot < p.cursor.Slot || slot >= hi {
+					continue
+				}
+				ref := fmt.Sprintf("%v@%v", match.OutputIndex, match.TransactionID)
+				if slot > p.cursor.Slot {
+					p.cursor = MatchesCursor{Slot: slot}
+					seen = map[string]struct{}{}
+				}
+				if _, ok := seen[ref]; ok {
+					continue
+				}
+				seen[ref] = struct{}{}
+				p.cursor.Yielded = append(p.cursor.Yielded, ref)
+				if !yield(match, nil) {
+					return
+				}
+			}
+			p.cursor = MatchesCursor{Slot: hi}
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/SundaeSwap-finance/kugo"
+	"github.com/SundaeSwap-finance/kugo/kugotest"
+	"github.com/tj/assert"
+)
+
+const address = "addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl8"
+
+// matchCounter counts the matches kupo returns, so tests can check that
+// pages don't overlap
+type matchCounter struct {
+	requests atomic.Int32
+	matches  atomic.Int32
+}
+
+func (c *matchCounter) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	var matches []json.RawMessage
+	if json.Unmarshal(body, &matches) == nil {
+		c.requests.Add(1)
+		c.matches.Add(int32(len(matches)))
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// ref names the output created by transaction tx at output index
+func ref(tx string, index int) string {
+	return fmt.Sprintf("%v@%v", index, strings.Repeat(tx, 64))
+}
+
+func output(tx string, txIndex, index int) kugo.Match {
+	return kugo.Match{
+		TransactionIndex: txIndex,
+		TransactionID:    strings.Repeat(tx, 64),
+		OutputIndex:      index,
+		Address:          address,
+	}
+}
+
+// newPaginatedChain has outputs on both sides of each 10 slot window's
+// boundaries, and several outputs in one slot
+func newPaginatedChain() *kugotest.Server {
+	block := func(slot int) kugo.Point {
+		return kugo.Point{SlotNo: slot, HeaderHash: fmt.Sprintf("%02x", slot)}
+	}
+	return kugotest.New().
+		RollForward(block(9), output("a", 0, 0), output("a", 0, 1)).
+		RollForward(block(10), output("b", 0, 0)).
+		RollForward(block(19), output("c", 0, 0)).
+		RollForward(
+			block(20),
+			output("e", 1, 0),
+			output("d", 0, 0),
+			output("d", 0, 1),
+		).
+		RollForward(block(49), output("f", 0, 0)).
+		RollForward(block(50), output("0", 0, 0))
+}
+
+var paginated = []string{
+	ref("a", 0), ref("a", 1),
+	ref("b", 0),
+	ref("c", 0),
+	ref("d", 0), ref("d", 1), ref("e", 0),
+	ref("f", 0),
+}
+
+func refs(matches []kugo.Match) []string {
+	var refs []string
+	for _, match := range matches {
+		refs = append(
+			refs,
+			fmt.Sprintf("%v@%v", match.OutputIndex, match.TransactionID),
+		)
+	}
+	return refs
+}
+
+func Test_MatchesPaginator(t *testing.T) {
+	t.Parallel()
+
+	server := newPaginatedChain().HTTP()
+	defer server.Close()
+	counter := &matchCounter{}
+	c := kugo.New(kugo.WithEndpoint(server.URL), kugo.WithTransport(counter))
+	ctx := context.Background()
+
+	var matches []kugo.Match
+	p := kugo.NewMatchesPaginator(c, 0, 50, 10, kugo.Address(address))
+	for m, err := range p.All(ctx) {
+		assert.Nil(t, err)
+		matches = append(matches, m)
+	}
+	assert.Equal(t, paginated, refs(matches))
+	assert.Equal(t, kugo.MatchesCursor{Slot: 50}, p.Cursor())
+	// One request per window, and kupo's exclusive bounds mean no output is
+	// fetched twice
+	assert.EqualValues(t, 5, counter.requests.Load())
+	assert.EqualValues(t, len(paginated), counter.matches.Load())
+}
+
+func Test_MatchesPaginatorToTip(t *testing.T) {
+	t.Parallel()
+
+	server := newPaginatedChain().HTTP()
+	defer server.Close()
+	c := kugo.New(kugo.WithEndpoint(server.URL))
+
+	var matches []kugo.Match
+	p := kugo.NewMatchesPaginator(c, 10, 0, 100, kugo.Address(address))
+	for m, err := range p.All(context.Background()) {
+		assert.Nil(t, err)
+		matches = append(matches, m)
+	}
+	// Up to and including the most recent checkpoint, at slot 50
+	assert.Equal(t, append(paginated[2:], ref("0", 0)), refs(matches))
+}
+
+func Test_MatchesPaginatorResume(t *testing.T) {
+	t.Parallel()
+
+	server := newPaginatedChain().HTTP()
+	defer server.Close()
+	c := kugo.New(kugo.WithEndpoint(server.URL))
+	ctx := context.Background()
+
+	// Stop part way through a slot each time, persist the cursor, and
+	// resume from it
+	var (
+		matches []kugo.Match
+		cursors []kugo.MatchesCursor
+		cursor  kugo.MatchesCursor
+	)
+	for _, stopAfter := range []int{1, 4, 2, 100} {
+		p := kugo.NewMatchesPaginator(c, 0, 50, 10, kugo.Address(address)).
+			ResumeFrom(cursor)
+		yielded := 0
+		for m, err := range p.All(ctx) {
+			assert.Nil(t, err)
+			matches = append(matches, m)
+			if yielded++; yielded == stopAfter {
+				break
+			}
+		}
+
+		saved, err := json.Marshal(p.Cursor())
+		assert.Nil(t, err)
+		cursor = kugo.MatchesCursor{}
+		assert.Nil(t, json.Unmarshal(saved, &cursor))
+		cursors = append(cursors, cursor)
+	}
+
+	assert.Equal(t, paginated, refs(matches))
+	assert.Equal(t, []kugo.MatchesCursor{
+		{Slot: 9, Yielded: []string{ref("a", 0)}},
+		{Slot: 20, Yielded: []string{ref("d", 0)}},
+		{Slot: 20, Yielded: []string{ref("d", 0), ref("d", 1), ref("e", 0)}},
+		{Slot: 50},
+	}, cursors)
+}
@@ -0,0 +1,287 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/SundaeSwap-finance/kugo/address"
+)
+
+type PatternKind int
+
+const (
+	PatternKindUnknown PatternKind = iota
+	// PatternKindWildcard matches everything: *
+	PatternKindWildcard
+	// PatternKindAddress matches a full address
+	PatternKindAddress
+	// PatternKindCredentials matches payment and/or delegation credentials:
+	// payment/delegation, where either side may be *
+	PatternKindCredentials
+	// PatternKindAsset matches a policy id and optional asset name:
+	// policy.asset or policy.*
+	PatternKindAsset
+	// PatternKindOutputReference matches a transaction output:
+	// index@txid or *@txid
+	PatternKindOutputReference
+)
+
+const wildcard = "*"
+
+// PatternSpec is a typed representation of a kupo pattern; build one with
+// the constructors below, or parse one with ParsePattern
+type PatternSpec struct {
+	Kind                 PatternKind
+	Address              string
+	PaymentCredential    string // * when any credential is allowed
+	DelegationCredential string // * when any credential is allowed
+	PolicyID             string
+	AssetName            string // * when any asset under the policy is allowed
+	TransactionID        string
+	OutputIndex          *int // nil when any output of the transaction is allowed
+}
+
+// AnyPattern matches everything
+func AnyPattern() PatternSpec {
+	return PatternSpec{Kind: PatternKindWildcard}
+}
+
+// AddressPattern matches outputs at a full address (bech32, base58 or hex)
+func AddressPattern(address string) PatternSpec {
+	return PatternSpec{Kind: PatternKindAddress, Address: address}
+}
+
+// CredentialsPattern matches outputs by payment and delegation credentials;
+// either may be * to match any
+func CredentialsPattern(payment, delegation string) PatternSpec {
+	return PatternSpec{
+		Kind:                 PatternKindCredentials,
+		PaymentCredential:    payment,
+		DelegationCredential: delegation,
+	}
+}
+
+// PaymentCredentialPattern matches outputs with the given payment credential
+// regardless of delegation: xxx/*
+func PaymentCredentialPattern(credential string) PatternSpec {
+	return CredentialsPattern(credential, wildcard)
+}
+
+// DelegationCredentialPattern matches outputs with the given delegation
+// credential regardless of payment: */xxx
+func DelegationCredentialPattern(credential string) PatternSpec {
+	return CredentialsPattern(wildcard, credential)
+}
+
+// AssetPattern matches outputs holding a specific asset: policy.asset
+func AssetPattern(policyID, assetName string) PatternSpec {
+	return PatternSpec{
+		Kind:      PatternKindAsset,
+		PolicyID:  policyID,
+		AssetName: assetName,
+	}
+}
+
+// PolicyPattern matches outputs holding any asset under a policy: policy.*
+func PolicyPattern(policyID string) PatternSpec {
+	return AssetPattern(policyID, wildcard)
+}
+
+// OutputReferencePattern matches a single transaction output: idx@txid
+func OutputReferencePattern(index int, txID string) PatternSpec {
+	return PatternSpec{
+		Kind:          PatternKindOutputReference,
+		TransactionID: txID,
+		OutputIndex:   &index,
+	}
+}
+
+// TransactionPattern matches every output of a transaction: *@txid
+func TransactionPattern(txID string) PatternSpec {
+	return PatternSpec{
+		Kind:          PatternKindOutputReference,
+		TransactionID: txID,
+	}
+}
+
+// ParsePattern converts a kupo pattern string into its typed form, and
+// validates it
+func ParsePattern(pattern string) (PatternSpec, error) {
+	var spec PatternSpec
+	switch {
+	case pattern == wildcard || pattern == "*/*":
+		spec = AnyPattern()
+	case strings.Contains(pattern, "@"):
+		index, txID, _ := strings.Cut(pattern, "@")
+		if index == wildcard {
+			spec = TransactionPattern(txID)
+		} else {
+			idx, err := strconv.Atoi(index)
+			if err != nil {
+				return PatternSpec{}, fmt.Errorf(
+					"invalid output index in pattern %v: %w",
+					pattern,
+					err,
+				)
+			}
+			spec = OutputReferencePattern(idx, txID)
+		}
+	case strings.Contains(pattern, "/"):
+		payment, delegation, _ := strings.Cut(pattern, "/")
+		spec = CredentialsPattern(payment, delegation)
+	case strings.Contains(pattern, "."):
+		policyID, assetName, _ := strings.Cut(pattern, ".")
+		spec = AssetPattern(policyID, assetName)
+	default:
+		spec = AddressPattern(pattern)
+	}
+	if err := spec.Validate(); err != nil {
+		return PatternSpec{}, err
+	}
+	return spec, nil
+}
+
+// String renders the pattern in the form kupo expects
+func (p PatternSpec) String() string {
+	switch p.Kind {
+	case PatternKindWildcard:
+		return wildcard
+	case PatternKindAddress:
+		return p.Address
+	case PatternKindCredentials:
+		return p.PaymentCredential + "/" + p.DelegationCredential
+	case PatternKindAsset:
+		return p.PolicyID + "." + p.AssetName
+	case PatternKindOutputReference:
+		if p.OutputIndex == nil {
+			return wildcard + "@" + p.TransactionID
+		}
+		return fmt.Sprintf("%v@%v", *p.OutputIndex, p.TransactionID)
+	default:
+		return ""
+	}
+}
+
+// Validate checks that each component of the pattern is well formed
+func (p PatternSpec) Validate() error {
+	switch p.Kind {
+	case PatternKindWildcard:
+		return nil
+	case PatternKindAddress:
+		if !isAddress(p.Address) {
+			return fmt.Errorf("invalid address in pattern: '%v'", p.Address)
+		}
+	case PatternKindCredentials:
+		for _, cred := range []string{p.PaymentCredential, p.DelegationCredential} {
+			if cred != wildcard && !isCredential(cred) {
+				return fmt.Errorf("invalid credential in pattern: '%v'", cred)
+			}
+		}
+	case PatternKindAsset:
+		if !isHex(p.PolicyID, 56) {
+			return fmt.Errorf("invalid policy id in pattern: '%v'", p.PolicyID)
+		}
+		if p.AssetName != wildcard &&
+			(len(p.AssetName) > 64 || !isHex(p.AssetName, len(p.AssetName))) {
+			return fmt.Errorf("invalid asset name in pattern: '%v'", p.AssetName)
+		}
+	case PatternKindOutputReference:
+		if !isHex(p.TransactionID, 64) {
+			return fmt.Errorf(
+				"invalid transaction id in pattern: '%v'",
+				p.TransactionID,
+			)
+		}
+		if p.OutputIndex != nil && *p.OutputIndex < 0 {
+			return fmt.Errorf(
+				"invalid output index in pattern: %v",
+				*p.OutputIndex,
+			)
+		}
+	default:
+		return fmt.Errorf("unknown pattern kind: %v", p.Kind)
+	}
+	return nil
+}
+
+func (p PatternSpec) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *PatternSpec) UnmarshalText(text []byte) error {
+	spec, err := ParsePattern(string(text))
+	if err != nil {
+		return err
+	}
+	*p = spec
+	return nil
+}
+
+func isHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// isAddress checks that s is a valid bech32 or Byron address, or the hex
+// encoded bytes of one
+func isAddress(s string) bool {
+	if _, err := address.Parse(s); err == nil {
+		return true
+	}
+	if len(s) == 0 || len(s)%2 != 0 {
+		return false
+	}
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return false
+	}
+	_, err = address.FromBytes(data)
+	return err == nil
+}
+
+func isCredential(s string) bool {
+	if isHex(s, 56) || isHex(s, 64) {
+		return true
+	}
+	prefix, data, err := address.DecodeBech32(s)
+	if err != nil {
+		return false
+	}
+	switch prefix {
+	case "addr_vkh", "addr_shared_vkh", "stake_vkh", "stake_shared_vkh",
+		"script":
+		return len(data) == 28
+	case "addr_vk", "addr_shared_vk", "stake_vk", "stake_shared_vk":
+		return len(data) == 32
+	default:
+		return false
+	}
+}
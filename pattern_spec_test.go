@@ -0,0 +1,88 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func Test_ParsePattern(t *testing.T) {
+	const (
+		cred   = "3c07030e36bfffe67e2e2ec09e5293d384637cd2f004356ef320f3fe"
+		policy = "4fc16c94d066e949e771c5581235f8090ad6aaffaf373a426445ca51"
+		txID   = "2222222222222222222222222222222222222222222222222222222222222222"
+		addr   = "addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl8"
+		vkh    = "addr_vkh18srsxr3khll7vl3w9mqfu55n6wzxxlxj7qzr2mhnyrelu8vh6w7"
+		byron  = "Ae2tdPwUPEZFRbyhz3cpfC2CumGzNkFBN2L42rcUc2yjQpEkxDbkPodpMAi"
+	)
+	type testCase struct {
+		pattern  string
+		expected PatternSpec
+	}
+	testCases := []testCase{
+		{pattern: "*", expected: AnyPattern()},
+		{pattern: addr, expected: AddressPattern(addr)},
+		{pattern: cred + "/*", expected: PaymentCredentialPattern(cred)},
+		{pattern: "*/" + cred, expected: DelegationCredentialPattern(cred)},
+		{pattern: cred + "/" + cred, expected: CredentialsPattern(cred, cred)},
+		{pattern: vkh + "/*", expected: PaymentCredentialPattern(vkh)},
+		{pattern: byron, expected: AddressPattern(byron)},
+		{pattern: "61" + cred, expected: AddressPattern("61" + cred)},
+		{pattern: policy + ".*", expected: PolicyPattern(policy)},
+		{pattern: policy + ".abcd", expected: AssetPattern(policy, "abcd")},
+		{pattern: "3@" + txID, expected: OutputReferencePattern(3, txID)},
+		{pattern: "*@" + txID, expected: TransactionPattern(txID)},
+	}
+	for _, tc := range testCases {
+		spec, err := ParsePattern(tc.pattern)
+		assert.Nil(t, err, tc.pattern)
+		assert.Equal(t, tc.expected, spec, tc.pattern)
+		assert.Equal(t, tc.pattern, spec.String(), tc.pattern)
+	}
+
+	invalid := []string{
+		"",
+		"abc/*",
+		"*/abc",
+		"abc.*",
+		policy + ".xyz",
+		"x@" + txID,
+		"1@abc",
+		"addr1_not_bech32!",
+		"abc",
+		"addr1qxtypo",
+		// a typo in the last character breaks the checksum
+		addr[:len(addr)-1] + "u",
+		vkh[:len(vkh)-1] + "8" + "/*",
+		"61" + cred[:54],
+	}
+	for _, pattern := range invalid {
+		_, err := ParsePattern(pattern)
+		assert.NotNil(t, err, pattern)
+	}
+
+	assert.NotNil(t, PatternSpec{}.Validate())
+}
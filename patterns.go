@@ -24,11 +24,11 @@
 package kugo
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"time"
 
@@ -62,13 +62,9 @@ func (c *Client) Patterns(ctx context.Context) (matches []string, err error) {
 		return nil, fmt.Errorf("failed to build request: %w", err)
 	}
 
-	req.Close = true
 	req = req.WithContext(ctx)
 
-	client := &http.Client{
-		Timeout: c.options.timeout,
-	}
-	resp, err := client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve patterns: %w", err)
 	}
@@ -77,9 +73,9 @@ func (c *Client) Patterns(ctx context.Context) (matches []string, err error) {
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := readResponse(resp)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %w", err)
+		return nil, err
 	}
 
 	matches = []string{}
@@ -92,3 +88,268 @@ func (c *Client) Patterns(ctx context.Context) (matches []string, err error) {
 	}
 	return matches, nil
 }
+
+// PatternSpecs returns the patterns kupo is indexing in their typed form
+func PatternSpecs(ctx context.Context, api API) ([]PatternSpec, error) {
+	patterns, err := api.Patterns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	specs := make([]PatternSpec, 0, len(patterns))
+	for _, pattern := range patterns {
+		spec, err := ParsePattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("kupo returned an invalid pattern: %w", err)
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// PatternLimit controls how far back kupo is allowed to roll back when a new
+// pattern is added
+type PatternLimit string
+
+const (
+	// WithinSafeZone only allows rollbacks within the last k blocks
+	WithinSafeZone PatternLimit = "within_safe_zone"
+	// UnsafeAllowBeyondSafeZone allows rolling back to any point; kupo may
+	// take a long time to resync
+	UnsafeAllowBeyondSafeZone PatternLimit = "unsafe_allow_beyond_safe_zone"
+)
+
+type patternOptions struct {
+	RollbackTo *rollbackPoint `json:"rollback_to,omitempty"`
+	Limit      PatternLimit   `json:"limit,omitempty"`
+}
+
+// rollbackPoint is a Point as kupo expects it in rollback_to; unlike Point,
+// slot_no is always sent, as kupo rejects a point without one
+type rollbackPoint struct {
+	SlotNo     int    `json:"slot_no"`
+	HeaderHash string `json:"header_hash,omitempty"`
+}
+
+type PatternOption func(*patternOptions)
+
+// RollbackTo asks kupo to rewind to the given point and re-index from there
+// once the new pattern is added; required by kupo when adding a pattern
+func RollbackTo(point Point) PatternOption {
+	return func(o *patternOptions) {
+		o.RollbackTo = &rollbackPoint{
+			SlotNo:     point.SlotNo,
+			HeaderHash: point.HeaderHash,
+		}
+	}
+}
+
+// RollbackLimit restricts how far back kupo is allowed to roll back
+func RollbackLimit(limit PatternLimit) PatternOption {
+	return func(o *patternOptions) {
+		o.Limit = limit
+	}
+}
+
+// AddPattern registers a new pattern with kupo, returning the full set of
+// patterns now being indexed
+func (c *Client) AddPattern(
+	ctx context.Context,
+	pattern PatternSpec,
+	opts ...PatternOption,
+) (patterns []string, err error) {
+	start := time.Now()
+	defer func() {
+		errStr := ""
+		if err != nil {
+			errStr = err.Error()
+		}
+		c.options.logger.Info(
+			"AddPattern() finished",
+			ogmigo.KV(
+				"duration",
+				time.Since(start).Round(time.Millisecond).String(),
+			),
+			ogmigo.KV("pattern", pattern.String()),
+			ogmigo.KV("err", errStr),
+		)
+	}()
+
+	if err := pattern.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	o := patternOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	reqBody, err := json.Marshal(o)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request body: %w", err)
+	}
+
+	req, err := http.NewRequest(
+		http.MethodPut,
+		fmt.Sprintf("%v/v1/patterns/%v", c.options.endpoint, pattern),
+		bytes.NewReader(reqBody),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(ctx)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add pattern: %w", err)
+	}
+	if resp == nil {
+		return nil, errors.New("failed with a nil response")
+	}
+	defer resp.Body.Close()
+
+	body, err := readResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns = []string{}
+	if err := json.Unmarshal(body, &patterns); err != nil {
+		return nil, fmt.Errorf(
+			"error parsing response %v: %w",
+			string(body),
+			err,
+		)
+	}
+	return patterns, nil
+}
+
+// RemovePattern stops kupo from indexing a pattern, returning the number of
+// patterns that were removed
+func (c *Client) RemovePattern(
+	ctx context.Context,
+	pattern PatternSpec,
+) (deleted int, err error) {
+	start := time.Now()
+	defer func() {
+		errStr := ""
+		if err != nil {
+			errStr = err.Error()
+		}
+		c.options.logger.Info(
+			"RemovePattern() finished",
+			ogmigo.KV(
+				"duration",
+				time.Since(start).Round(time.Millisecond).String(),
+			),
+			ogmigo.KV("pattern", pattern.String()),
+			ogmigo.KV("deleted", fmt.Sprintf("%v", deleted)),
+			ogmigo.KV("err", errStr),
+		)
+	}()
+
+	if err := pattern.Validate(); err != nil {
+		return 0, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	req, err := http.NewRequest(
+		http.MethodDelete,
+		fmt.Sprintf("%v/v1/patterns/%v", c.options.endpoint, pattern),
+		nil,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req = req.WithContext(ctx)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove pattern: %w", err)
+	}
+	if resp == nil {
+		return 0, errors.New("failed with a nil response")
+	}
+	defer resp.Body.Close()
+
+	body, err := readResponse(resp)
+	if err != nil {
+		return 0, err
+	}
+
+	var response struct {
+		Deleted int `json:"deleted"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return 0, fmt.Errorf(
+			"error parsing response %v: %w",
+			string(body),
+			err,
+		)
+	}
+	return response.Deleted, nil
+}
+
+// PatternIncluded reports whether the given pattern is covered by any of the
+// patterns kupo is currently indexing
+func (c *Client) PatternIncluded(
+	ctx context.Context,
+	pattern PatternSpec,
+) (included bool, err error) {
+	start := time.Now()
+	defer func() {
+		errStr := ""
+		if err != nil {
+			errStr = err.Error()
+		}
+		c.options.logger.Info(
+			"PatternIncluded() finished",
+			ogmigo.KV(
+				"duration",
+				time.Since(start).Round(time.Millisecond).String(),
+			),
+			ogmigo.KV("pattern", pattern.String()),
+			ogmigo.KV("included", fmt.Sprintf("%v", included)),
+			ogmigo.KV("err", errStr),
+		)
+	}()
+
+	if err := pattern.Validate(); err != nil {
+		return false, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	req, err := http.NewRequest(
+		http.MethodGet,
+		fmt.Sprintf("%v/v1/patterns/%v", c.options.endpoint, pattern),
+		nil,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req = req.WithContext(ctx)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to retrieve patterns: %w", err)
+	}
+	if resp == nil {
+		return false, errors.New("failed with a nil response")
+	}
+	defer resp.Body.Close()
+
+	body, err := readResponse(resp)
+	if err != nil {
+		return false, err
+	}
+
+	var patterns []string
+	if err := json.Unmarshal(body, &patterns); err != nil {
+		return false, fmt.Errorf(
+			"error parsing response %v: %w",
+			string(body),
+			err,
+		)
+	}
+	return len(patterns) > 0, nil
+}
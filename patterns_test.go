@@ -21,25 +21,97 @@
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
 // SOFTWARE.
 
-package kugo
+package kugo_test
 
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/SundaeSwap-finance/kugo"
+	"github.com/SundaeSwap-finance/kugo/kugotest"
 	"github.com/tj/assert"
 )
 
 func Test_Patterns(t *testing.T) {
 	t.Parallel()
-	server := NewMockServer().AddPatterns("*").HTTP()
+	server := kugotest.New().HTTP()
 	defer server.Close()
 
-	c := New(WithEndpoint(server.URL))
+	c := kugo.New(kugo.WithEndpoint(server.URL))
 	patterns, err := c.Patterns(context.Background())
 	assert.Nil(t, err)
 	assert.NotZero(t, len(patterns))
 
 	fmt.Printf("Patterns: %v\n", patterns)
 }
+
+func Test_AddRemovePattern(t *testing.T) {
+	t.Parallel()
+	server := kugotest.New().SetPatterns("addr1").HTTP()
+	defer server.Close()
+
+	c := kugo.New(kugo.WithEndpoint(server.URL))
+	ctx := context.Background()
+	pattern := kugo.PolicyPattern(
+		"4fc16c94d066e949e771c5581235f8090ad6aaffaf373a426445ca51",
+	)
+
+	_, err := c.AddPattern(ctx, pattern)
+	assert.NotNil(t, err)
+
+	_, err = c.AddPattern(
+		ctx,
+		kugo.PolicyPattern("abc"),
+		kugo.RollbackTo(kugo.Point{}),
+	)
+	assert.NotNil(t, err)
+
+	patterns, err := c.AddPattern(
+		ctx,
+		pattern,
+		kugo.RollbackTo(kugo.Point{SlotNo: 100, HeaderHash: "abc"}),
+		kugo.RollbackLimit(kugo.WithinSafeZone),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"addr1", pattern.String()}, patterns)
+
+	included, err := c.PatternIncluded(ctx, pattern)
+	assert.Nil(t, err)
+	assert.True(t, included)
+
+	deleted, err := c.RemovePattern(ctx, pattern)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, deleted)
+
+	included, err = c.PatternIncluded(ctx, pattern)
+	assert.Nil(t, err)
+	assert.False(t, included)
+}
+
+func Test_AddPatternRollbackToOrigin(t *testing.T) {
+	t.Parallel()
+
+	var body string
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, _ := io.ReadAll(r.Body)
+			body = string(data)
+			_, _ = w.Write([]byte(`["*"]`))
+		}),
+	)
+	defer server.Close()
+
+	c := kugo.New(kugo.WithEndpoint(server.URL))
+	_, err := c.AddPattern(
+		context.Background(),
+		kugo.AnyPattern(),
+		kugo.RollbackTo(kugo.Point{SlotNo: 0}),
+	)
+	assert.Nil(t, err)
+	// kupo rejects a rollback_to without a slot_no, even for slot 0
+	assert.Equal(t, `{"rollback_to":{"slot_no":0}}`, body)
+}
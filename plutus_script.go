@@ -0,0 +1,173 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/SundaeSwap-finance/kugo/plutusdata"
+)
+
+// ProgramVersion is the version of an untyped plutus core program; 1.0.0
+// for plutus v1 and v2 scripts, 1.1.0 for plutus v3 scripts
+type ProgramVersion struct {
+	Major uint64
+	Minor uint64
+	Patch uint64
+}
+
+func (v ProgramVersion) String() string {
+	return fmt.Sprintf("%v.%v.%v", v.Major, v.Minor, v.Patch)
+}
+
+func (s Script) isPlutus() bool {
+	switch s.Language {
+	case ScriptLanguagePlutusV1, ScriptLanguagePlutusV2, ScriptLanguagePlutusV3:
+		return true
+	}
+	return false
+}
+
+// Normalize returns the script in the form the ledger hashes: the flat
+// encoded program wrapped in exactly one CBOR byte string. Scripts may be
+// found unwrapped, or double wrapped as in cardano-cli's text envelopes.
+func (s Script) Normalize() (Script, error) {
+	serialized, _, err := s.unwrap()
+	if err != nil {
+		return Script{}, err
+	}
+	return Script{Language: s.Language, Script: hex.EncodeToString(serialized)}, nil
+}
+
+// Flat returns the flat encoded program, with any CBOR wrapping removed
+func (s Script) Flat() ([]byte, error) {
+	_, flat, err := s.unwrap()
+	return flat, err
+}
+
+// FlatSize returns the size in bytes of the flat encoded program
+func (s Script) FlatSize() (int, error) {
+	flat, err := s.Flat()
+	if err != nil {
+		return 0, err
+	}
+	return len(flat), nil
+}
+
+// ProgramVersion returns the untyped plutus core version of the program
+func (s Script) ProgramVersion() (ProgramVersion, error) {
+	flat, err := s.Flat()
+	if err != nil {
+		return ProgramVersion{}, err
+	}
+	version, _, err := readProgramVersion(flat)
+	return version, err
+}
+
+// unwrap peels CBOR byte strings off a plutus script, returning the
+// innermost wrapped form, as the ledger serializes it, and the flat program
+func (s Script) unwrap() (serialized, flat []byte, err error) {
+	if !s.isPlutus() {
+		return nil, nil, errors.New("script is not a plutus script")
+	}
+	flat, err = hex.DecodeString(s.Script)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid script hex: %w", err)
+	}
+	// A flat program starts with its version, an unsigned integer in CBOR
+	// terms, so it can never be mistaken for a byte string
+	for {
+		node, err := plutusdata.Decode(flat)
+		if err != nil {
+			break
+		}
+		inner, ok := node.(plutusdata.Bytes)
+		if !ok {
+			break
+		}
+		serialized, flat = flat, inner.Value
+	}
+
+	version, _, err := readProgramVersion(flat)
+	if err != nil {
+		return nil, nil, err
+	}
+	if version.Major != 1 {
+		return nil, nil, fmt.Errorf("unsupported plutus program version %v", version)
+	}
+	if serialized == nil {
+		serialized = wrapBytes(flat)
+	}
+	return serialized, flat, nil
+}
+
+// readProgramVersion reads the three naturals that start a flat program;
+// naturals are written as 7 bit groups, least significant first, with the
+// high bit set on all but the last, and the program is byte aligned
+func readProgramVersion(flat []byte) (ProgramVersion, int, error) {
+	var parts [3]uint64
+	pos := 0
+	for i := range parts {
+		var value uint64
+		for shift := uint(0); ; shift += 7 {
+			if pos >= len(flat) {
+				return ProgramVersion{}, 0, errors.New("flat program is too short to hold a version")
+			}
+			if shift > 63 {
+				return ProgramVersion{}, 0, errors.New("flat program version overflows")
+			}
+			b := flat[pos]
+			pos++
+			value |= uint64(b&0x7f) << shift
+			if b&0x80 == 0 {
+				break
+			}
+		}
+		parts[i] = value
+	}
+	return ProgramVersion{Major: parts[0], Minor: parts[1], Patch: parts[2]}, pos, nil
+}
+
+// wrapBytes wraps data in a definite length CBOR byte string
+func wrapBytes(data []byte) []byte {
+	n := uint64(len(data))
+	var head []byte
+	switch {
+	case n < 24:
+		head = []byte{0x40 | byte(n)}
+	case n <= math.MaxUint8:
+		head = []byte{0x58, byte(n)}
+	case n <= math.MaxUint16:
+		head = binary.BigEndian.AppendUint16([]byte{0x59}, uint16(n))
+	case n <= math.MaxUint32:
+		head = binary.BigEndian.AppendUint32([]byte{0x5a}, uint32(n))
+	default:
+		head = binary.BigEndian.AppendUint64([]byte{0x5b}, n)
+	}
+	return append(head, data...)
+}
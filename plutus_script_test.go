@@ -0,0 +1,105 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/SundaeSwap-finance/kugo/address"
+	"github.com/tj/assert"
+)
+
+func TestScript_PlutusIntrospection(t *testing.T) {
+	const flat = "01000033222220051200120011"
+	const single = "4d" + flat
+	const double = "4e" + single
+
+	for name, wrapped := range map[string]string{
+		"flat":   flat,
+		"single": single,
+		"double": double,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			script := Script{Language: ScriptLanguagePlutusV1, Script: wrapped}
+			assert.Equal(
+				t,
+				"67f33146617a5e61936081db3b2117cbf59bd2123748f58ac9678656",
+				hex.EncodeToString(script.Hash()),
+			)
+
+			normalized, err := script.Normalize()
+			assert.Nil(t, err)
+			assert.Equal(t, single, normalized.Script)
+
+			size, err := script.FlatSize()
+			assert.Nil(t, err)
+			assert.Equal(t, 13, size)
+
+			version, err := script.ProgramVersion()
+			assert.Nil(t, err)
+			assert.Equal(t, "1.0.0", version.String())
+		})
+	}
+
+	t.Run("large script", func(t *testing.T) {
+		t.Parallel()
+
+		program := "010100" + strings.Repeat("33", 300)
+		script := Script{Language: ScriptLanguagePlutusV3, Script: "59012f" + program}
+		normalized, err := script.Normalize()
+		assert.Nil(t, err)
+		assert.Equal(t, script, normalized)
+
+		unwrapped := Script{Language: ScriptLanguagePlutusV3, Script: program}
+		assert.Equal(t, script.Hash(), unwrapped.Hash())
+
+		version, err := script.ProgramVersion()
+		assert.Nil(t, err)
+		assert.Equal(t, ProgramVersion{Major: 1, Minor: 1}, version)
+	})
+
+	t.Run("not a plutus program", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Script{Language: ScriptLanguageNative, Script: single}.Flat()
+		assert.NotNil(t, err)
+		_, err = Script{Language: ScriptLanguagePlutusV2, Script: "8201838200581c"}.Flat()
+		assert.NotNil(t, err)
+		_, err = Script{Language: ScriptLanguagePlutusV2, Script: "4101"}.ProgramVersion()
+		assert.NotNil(t, err)
+	})
+}
+
+func TestScript_Address(t *testing.T) {
+	script := Script{Language: ScriptLanguagePlutusV1, Script: "4e4d01000033222220051200120011"}
+	assert.Equal(
+		t,
+		"addr_test1wpnlxv2xv9a9ucvnvzqakwepzl9ltx7jzgm53av2e9ncv4sysemm8",
+		script.Address(address.Testnet),
+	)
+}
@@ -0,0 +1,600 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package plutusdata
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+const (
+	majorUnsigned = 0
+	majorNegative = 1
+	majorBytes    = 2
+	majorText     = 3
+	majorArray    = 4
+	majorMap      = 5
+	majorTag      = 6
+	majorSimple   = 7
+
+	// breakCode terminates an indefinite length item
+	breakCode = 0xff
+	// chunkSize is the largest byte string the ledger writes in one piece
+	chunkSize = 64
+	// maxDepth bounds nesting, so hostile input can't exhaust the stack
+	maxDepth = 1024
+
+	tagPositiveBignum = 2
+	tagNegativeBignum = 3
+	tagConstrGeneral  = 102
+	tagConstr0        = 121  // constructors 0-6
+	tagConstr7        = 1280 // constructors 7-127
+)
+
+// form records how a CBOR head was written
+type form byte
+
+const (
+	formMinimal    form = 0
+	formIndefinite form = 31
+	// 24-27 record an explicit 1, 2, 4 or 8 byte argument
+)
+
+// encoding remembers how a decoded node was written, so it can be
+// re-encoded to the same bytes
+type encoding struct {
+	// forms of the heads the node itself wrote, in order; nil for nodes that
+	// weren't decoded, which use the ledger's defaults
+	forms []form
+	// chunks holds the length of each chunk of an indefinite byte string
+	chunks []int
+	// general is set for constructors written with tag 102, even though a
+	// compact tag was available
+	general bool
+	// bignum is set for integers written as tagged byte strings, and
+	// magnitude is the length of that byte string, leading zeros included
+	bignum    bool
+	magnitude int
+}
+
+// formReader hands out the recorded forms of a node one at a time
+type formReader struct {
+	forms []form
+}
+
+func (r *formReader) next() (form, bool) {
+	if len(r.forms) == 0 {
+		return formMinimal, false
+	}
+	f := r.forms[0]
+	r.forms = r.forms[1:]
+	return f, true
+}
+
+// Decode parses CBOR encoded Plutus data; the entire input must be consumed
+func Decode(data []byte) (Data, error) {
+	d := decoder{data: data}
+	node, err := d.decode(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, fmt.Errorf(
+			"unexpected %v trailing bytes after plutus data",
+			len(d.data)-d.pos,
+		)
+	}
+	return node, nil
+}
+
+// DecodeHex parses hex encoded CBOR, as returned by kupo
+func DecodeHex(s string) (Data, error) {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex: %w", err)
+	}
+	return Decode(data)
+}
+
+// Encode serializes the tree to CBOR
+func Encode(node Data) ([]byte, error) {
+	var e encoder
+	if err := e.encode(node, 0); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+// EncodeHex serializes the tree to hex encoded CBOR
+func EncodeHex(node Data) (string, error) {
+	data, err := Encode(node)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+var errUnexpectedEnd = errors.New("unexpected end of plutus data")
+
+// head reads an item's initial byte and argument
+func (d *decoder) head() (major byte, value uint64, f form, err error) {
+	if d.pos >= len(d.data) {
+		return 0, 0, 0, errUnexpectedEnd
+	}
+	initial := d.data[d.pos]
+	d.pos++
+	major, info := initial>>5, initial&0x1f
+
+	switch {
+	case info < 24:
+		return major, uint64(info), formMinimal, nil
+	case info == 31:
+		// Only strings and collections have an indefinite length form; a
+		// break outside one is caught by the caller
+		if major != majorBytes && major != majorText &&
+			major != majorArray && major != majorMap {
+			return 0, 0, 0, fmt.Errorf("invalid cbor head 0x%02x", initial)
+		}
+		return major, 0, formIndefinite, nil
+	case info > 27:
+		return 0, 0, 0, fmt.Errorf("invalid cbor head 0x%02x", initial)
+	}
+
+	size := 1 << (info - 24)
+	if d.pos+size > len(d.data) {
+		return 0, 0, 0, errUnexpectedEnd
+	}
+	raw := d.data[d.pos : d.pos+size]
+	d.pos += size
+	switch size {
+	case 1:
+		value = uint64(raw[0])
+	case 2:
+		value = uint64(binary.BigEndian.Uint16(raw))
+	case 4:
+		value = uint64(binary.BigEndian.Uint32(raw))
+	case 8:
+		value = binary.BigEndian.Uint64(raw)
+	}
+	f = form(info)
+	if minimalInfo(value) == info {
+		f = formMinimal
+	}
+	return major, value, f, nil
+}
+
+func (d *decoder) isBreak() bool {
+	return d.pos < len(d.data) && d.data[d.pos] == breakCode
+}
+
+func (d *decoder) decode(depth int) (Data, error) {
+	if depth > maxDepth {
+		return nil, errors.New("plutus data nested too deeply")
+	}
+	start := d.pos
+	major, value, f, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	enc := encoding{forms: []form{f}}
+
+	switch major {
+	case majorUnsigned:
+		return Integer{Value: new(big.Int).SetUint64(value), enc: enc}, nil
+
+	case majorNegative:
+		n := new(big.Int).SetUint64(value)
+		return Integer{Value: n.Neg(n).Sub(n, big.NewInt(1)), enc: enc}, nil
+
+	case majorBytes:
+		d.pos = start
+		value, enc, err := d.bytes()
+		if err != nil {
+			return nil, err
+		}
+		return Bytes{Value: value, enc: enc}, nil
+
+	case majorArray:
+		items, err := d.items(value, f, depth)
+		if err != nil {
+			return nil, err
+		}
+		return List{Items: items, enc: enc}, nil
+
+	case majorMap:
+		var pairs []Pair
+		for i := uint64(0); f == formIndefinite || i < value; i++ {
+			if f == formIndefinite && d.isBreak() {
+				d.pos++
+				break
+			}
+			key, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			val, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, Pair{Key: key, Value: val})
+		}
+		return Map{Pairs: pairs, enc: enc}, nil
+
+	case majorTag:
+		return d.tagged(value, enc, depth)
+
+	default:
+		return nil, fmt.Errorf("unsupported cbor major type %v in plutus data", major)
+	}
+}
+
+func (d *decoder) tagged(tag uint64, enc encoding, depth int) (Data, error) {
+	switch {
+	case tag == tagPositiveBignum || tag == tagNegativeBignum:
+		magnitude, bytesEnc, err := d.bytes()
+		if err != nil {
+			return nil, fmt.Errorf("invalid bignum: %w", err)
+		}
+		enc.forms = append(enc.forms, bytesEnc.forms...)
+		enc.chunks = bytesEnc.chunks
+		enc.bignum = true
+		enc.magnitude = len(magnitude)
+		n := new(big.Int).SetBytes(magnitude)
+		if tag == tagNegativeBignum {
+			n.Neg(n).Sub(n, big.NewInt(1))
+		}
+		return Integer{Value: n, enc: enc}, nil
+
+	case tag >= tagConstr0 && tag < tagConstr0+7:
+		fields, err := d.fields(&enc, depth)
+		if err != nil {
+			return nil, err
+		}
+		return Constr{Index: tag - tagConstr0, Fields: fields, enc: enc}, nil
+
+	case tag >= tagConstr7 && tag < tagConstr7+121:
+		fields, err := d.fields(&enc, depth)
+		if err != nil {
+			return nil, err
+		}
+		return Constr{Index: tag - tagConstr7 + 7, Fields: fields, enc: enc}, nil
+
+	case tag == tagConstrGeneral:
+		major, length, f, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if major != majorArray || f == formIndefinite || length != 2 {
+			return nil, errors.New("invalid constructor: expected a 2 element array")
+		}
+		enc.forms = append(enc.forms, f)
+		major, index, f, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if major != majorUnsigned {
+			return nil, errors.New("invalid constructor: expected an unsigned index")
+		}
+		enc.forms = append(enc.forms, f)
+		fields, err := d.fields(&enc, depth)
+		if err != nil {
+			return nil, err
+		}
+		enc.general = index < 128
+		return Constr{Index: index, Fields: fields, enc: enc}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported cbor tag %v in plutus data", tag)
+	}
+}
+
+// fields reads the list of constructor fields, recording its form in enc
+func (d *decoder) fields(enc *encoding, depth int) ([]Data, error) {
+	major, length, f, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	if major != majorArray {
+		return nil, errors.New("invalid constructor: expected an array of fields")
+	}
+	enc.forms = append(enc.forms, f)
+	return d.items(length, f, depth)
+}
+
+func (d *decoder) items(length uint64, f form, depth int) ([]Data, error) {
+	if f != formIndefinite && length > uint64(len(d.data)-d.pos) {
+		return nil, errUnexpectedEnd
+	}
+	var items []Data
+	for i := uint64(0); f == formIndefinite || i < length; i++ {
+		if f == formIndefinite && d.isBreak() {
+			d.pos++
+			break
+		}
+		item, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// bytes reads a definite or chunked indefinite byte string
+func (d *decoder) bytes() ([]byte, encoding, error) {
+	major, length, f, err := d.head()
+	if err != nil {
+		return nil, encoding{}, err
+	}
+	if major != majorBytes {
+		return nil, encoding{}, errors.New("expected a byte string")
+	}
+	enc := encoding{forms: []form{f}}
+	if f != formIndefinite {
+		value, err := d.read(length)
+		return value, enc, err
+	}
+
+	value := []byte{}
+	enc.chunks = []int{}
+	for !d.isBreak() {
+		major, length, f, err := d.head()
+		if err != nil {
+			return nil, encoding{}, err
+		}
+		if major != majorBytes || f == formIndefinite {
+			return nil, encoding{}, errors.New("invalid byte string chunk")
+		}
+		chunk, err := d.read(length)
+		if err != nil {
+			return nil, encoding{}, err
+		}
+		value = append(value, chunk...)
+		enc.forms = append(enc.forms, f)
+		enc.chunks = append(enc.chunks, len(chunk))
+	}
+	d.pos++
+	return value, enc, nil
+}
+
+func (d *decoder) read(length uint64) ([]byte, error) {
+	if length > uint64(len(d.data)-d.pos) {
+		return nil, errUnexpectedEnd
+	}
+	value := append([]byte{}, d.data[d.pos:d.pos+int(length)]...)
+	d.pos += int(length)
+	return value, nil
+}
+
+type encoder struct {
+	buf []byte
+}
+
+// minimalInfo is the additional info of the shortest head for value
+func minimalInfo(value uint64) byte {
+	switch {
+	case value < 24:
+		return byte(value)
+	case value <= math.MaxUint8:
+		return 24
+	case value <= math.MaxUint16:
+		return 25
+	case value <= math.MaxUint32:
+		return 26
+	default:
+		return 27
+	}
+}
+
+func (e *encoder) head(major byte, value uint64, f form) {
+	if f == formIndefinite {
+		e.buf = append(e.buf, major<<5|byte(formIndefinite))
+		return
+	}
+	info := minimalInfo(value)
+	// Honor a recorded explicit width, as long as the value still fits
+	if f >= 24 && f <= 27 && byte(f) >= info {
+		info = byte(f)
+	}
+	if info < 24 {
+		e.buf = append(e.buf, major<<5|info)
+		return
+	}
+	e.buf = append(e.buf, major<<5|info)
+	switch info {
+	case 24:
+		e.buf = append(e.buf, byte(value))
+	case 25:
+		e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(value))
+	case 26:
+		e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(value))
+	case 27:
+		e.buf = binary.BigEndian.AppendUint64(e.buf, value)
+	}
+}
+
+// list writes a sequence of items; the ledger writes non-empty lists with
+// indefinite length
+func (e *encoder) list(items []Data, forms *formReader, depth int) error {
+	f, ok := forms.next()
+	if !ok && len(items) > 0 {
+		f = formIndefinite
+	}
+	e.head(majorArray, uint64(len(items)), f)
+	for _, item := range items {
+		if err := e.encode(item, depth+1); err != nil {
+			return err
+		}
+	}
+	if f == formIndefinite {
+		e.buf = append(e.buf, breakCode)
+	}
+	return nil
+}
+
+// bytes writes a byte string; the ledger splits strings longer than 64
+// bytes into 64 byte chunks
+func (e *encoder) bytes(value []byte, forms *formReader, chunks []int) {
+	f, ok := forms.next()
+	if !ok && len(value) > chunkSize {
+		f = formIndefinite
+	}
+	if f != formIndefinite {
+		e.head(majorBytes, uint64(len(value)), f)
+		e.buf = append(e.buf, value...)
+		return
+	}
+
+	total := 0
+	for _, chunk := range chunks {
+		total += chunk
+	}
+	if total != len(value) {
+		chunks = nil
+		for i := 0; i < len(value); i += chunkSize {
+			chunks = append(chunks, min(chunkSize, len(value)-i))
+		}
+	}
+	e.head(majorBytes, 0, formIndefinite)
+	for _, chunk := range chunks {
+		f, _ := forms.next()
+		e.head(majorBytes, uint64(chunk), f)
+		e.buf = append(e.buf, value[:chunk]...)
+		value = value[chunk:]
+	}
+	e.buf = append(e.buf, breakCode)
+}
+
+func (e *encoder) encode(node Data, depth int) error {
+	if depth > maxDepth {
+		return errors.New("plutus data nested too deeply")
+	}
+	switch n := node.(type) {
+	case Constr:
+		return e.constr(n, depth)
+
+	case Map:
+		forms := formReader{forms: n.enc.forms}
+		f, _ := forms.next()
+		e.head(majorMap, uint64(len(n.Pairs)), f)
+		for _, pair := range n.Pairs {
+			if err := e.encode(pair.Key, depth+1); err != nil {
+				return err
+			}
+			if err := e.encode(pair.Value, depth+1); err != nil {
+				return err
+			}
+		}
+		if f == formIndefinite {
+			e.buf = append(e.buf, breakCode)
+		}
+		return nil
+
+	case List:
+		forms := formReader{forms: n.enc.forms}
+		return e.list(n.Items, &forms, depth)
+
+	case Integer:
+		e.integer(n)
+		return nil
+
+	case Bytes:
+		forms := formReader{forms: n.enc.forms}
+		e.bytes(n.Value, &forms, n.enc.chunks)
+		return nil
+
+	case nil:
+		return errors.New("unable to encode nil plutus data")
+
+	default:
+		return fmt.Errorf("unable to encode plutus data of type %T", node)
+	}
+}
+
+func (e *encoder) constr(c Constr, depth int) error {
+	forms := formReader{forms: c.enc.forms}
+	f, _ := forms.next()
+	switch {
+	case c.enc.general || c.Index >= 128:
+		e.head(majorTag, tagConstrGeneral, f)
+		f, _ = forms.next()
+		e.head(majorArray, 2, f)
+		f, _ = forms.next()
+		e.head(majorUnsigned, c.Index, f)
+	case c.Index < 7:
+		e.head(majorTag, tagConstr0+c.Index, f)
+	default:
+		e.head(majorTag, tagConstr7+c.Index-7, f)
+	}
+	return e.list(c.Fields, &forms, depth)
+}
+
+func (e *encoder) integer(i Integer) {
+	value := i.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	forms := formReader{forms: i.enc.forms}
+	f, _ := forms.next()
+
+	if !i.enc.bignum {
+		if value.Sign() >= 0 && value.IsUint64() {
+			e.head(majorUnsigned, value.Uint64(), f)
+			return
+		}
+		if n := negativeArgument(value); n.Sign() >= 0 && n.IsUint64() {
+			e.head(majorNegative, n.Uint64(), f)
+			return
+		}
+	}
+
+	tag := uint64(tagPositiveBignum)
+	magnitude := value
+	if value.Sign() < 0 {
+		tag = tagNegativeBignum
+		magnitude = negativeArgument(value)
+	}
+	e.head(majorTag, tag, f)
+	raw := magnitude.Bytes()
+	// Preserve any leading zeros the original encoding had
+	if i.enc.magnitude > len(raw) {
+		raw = append(make([]byte, i.enc.magnitude-len(raw)), raw...)
+	}
+	e.bytes(raw, &forms, i.enc.chunks)
+}
+
+// negativeArgument converts a negative integer to its cbor argument, -1 - n
+func negativeArgument(value *big.Int) *big.Int {
+	n := new(big.Int).Neg(value)
+	return n.Sub(n, big.NewInt(1))
+}
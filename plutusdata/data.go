@@ -0,0 +1,136 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package plutusdata decodes, encodes and renders Plutus data, the CBOR
+// structure used for datums and redeemers on Cardano.
+//
+// Decoding remembers how each node was encoded (definite or indefinite
+// lengths, byte string chunking, non-minimal integer widths), so that
+// re-encoding a decoded tree reproduces the original bytes exactly; this
+// matters because datum hashes are computed over those bytes. Nodes built
+// by hand are encoded the way the Cardano ledger does. General purpose
+// CBOR libraries, such as fxamacker/cbor, decode to Go values and discard
+// those details, which is why the codec here is written by hand.
+package plutusdata
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Data is a node in a Plutus data tree: Constr, Map, List, Integer or Bytes
+type Data interface {
+	plutusData()
+}
+
+// Constr is a constructor application, e.g. a variant of a sum type
+type Constr struct {
+	Index  uint64
+	Fields []Data
+	enc    encoding
+}
+
+// Map is an association list; order and duplicate keys are preserved
+type Map struct {
+	Pairs []Pair
+	enc   encoding
+}
+
+type Pair struct {
+	Key   Data
+	Value Data
+}
+
+type List struct {
+	Items []Data
+	enc   encoding
+}
+
+type Integer struct {
+	Value *big.Int
+	enc   encoding
+}
+
+type Bytes struct {
+	Value []byte
+	enc   encoding
+}
+
+func (Constr) plutusData()  {}
+func (Map) plutusData()     {}
+func (List) plutusData()    {}
+func (Integer) plutusData() {}
+func (Bytes) plutusData()   {}
+
+// NewConstr builds a constructor application
+func NewConstr(index uint64, fields ...Data) Constr {
+	return Constr{Index: index, Fields: fields}
+}
+
+func NewMap(pairs ...Pair) Map {
+	return Map{Pairs: pairs}
+}
+
+func NewList(items ...Data) List {
+	return List{Items: items}
+}
+
+func NewInteger(value *big.Int) Integer {
+	return Integer{Value: value}
+}
+
+func NewInt64(value int64) Integer {
+	return Integer{Value: big.NewInt(value)}
+}
+
+func NewBytes(value []byte) Bytes {
+	return Bytes{Value: value}
+}
+
+// String renders the tree in a compact, human readable form
+func (c Constr) String() string {
+	return fmt.Sprintf("Constr %v %v", c.Index, c.Fields)
+}
+
+func (m Map) String() string {
+	return fmt.Sprintf("Map %v", m.Pairs)
+}
+
+func (p Pair) String() string {
+	return fmt.Sprintf("(%v, %v)", p.Key, p.Value)
+}
+
+func (l List) String() string {
+	return fmt.Sprintf("List %v", l.Items)
+}
+
+func (i Integer) String() string {
+	if i.Value == nil {
+		return "0"
+	}
+	return i.Value.String()
+}
+
+func (b Bytes) String() string {
+	return fmt.Sprintf("#%x", b.Value)
+}
@@ -0,0 +1,176 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package plutusdata
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestDecode_RoundTrip(t *testing.T) {
+	cases := map[string]string{
+		"unit constructor":        "d87980",
+		"indefinite fields":       "d8799f4102ff",
+		"definite fields":         "d879824102182a",
+		"compact constructor 7":   "d905008100",
+		"general constructor":     "d8668218c980",
+		"general small index":     "d866820080",
+		"non-minimal int":         "d879811900ff",
+		"negative int":            "d8798120",
+		"positive bignum":         "c249010000000000000000",
+		"negative bignum":         "c349010000000000000000",
+		"small bignum":            "c24101",
+		"bignum leading zeros":    "c2420001",
+		"chunked bignum zeros":    "c25f41004101ff",
+		"empty bignum":            "c240",
+		"indefinite map":          "bf0102ff",
+		"definite map":            "a2010203d87980",
+		"empty indefinite list":   "9fff",
+		"nested":                  "d8799fd8799f581c00112233445566778899aabbccddeeff00112233445566778899aabbffd87a80ff",
+		"chunked bytes":           "5f4201024103ff",
+		"non-minimal bytes head":  "580201 02",
+		"non-minimal list head":   "980100",
+		"explicit 8 byte integer": "1b0000000000000001",
+	}
+	for name, input := range cases {
+		input := strings.ReplaceAll(input, " ", "")
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			node, err := DecodeHex(input)
+			assert.Nil(t, err)
+
+			got, err := EncodeHex(node)
+			assert.Nil(t, err)
+			assert.Equal(t, input, got)
+		})
+	}
+}
+
+func TestDecode_Values(t *testing.T) {
+	node, err := DecodeHex("d8799f4102d87a9f1a000f4240ffa1413320ff")
+	assert.Nil(t, err)
+
+	constr, ok := node.(Constr)
+	assert.True(t, ok)
+	assert.EqualValues(t, 0, constr.Index)
+	assert.Len(t, constr.Fields, 3)
+	assert.Equal(t, []byte{0x02}, constr.Fields[0].(Bytes).Value)
+
+	inner := constr.Fields[1].(Constr)
+	assert.EqualValues(t, 1, inner.Index)
+	assert.EqualValues(t, 1000000, inner.Fields[0].(Integer).Value.Int64())
+
+	pairs := constr.Fields[2].(Map).Pairs
+	assert.Len(t, pairs, 1)
+	assert.EqualValues(t, -1, pairs[0].Value.(Integer).Value.Int64())
+
+	big, err := DecodeHex("c349010000000000000000")
+	assert.Nil(t, err)
+	assert.Equal(t, "-18446744073709551617", big.(Integer).Value.String())
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"empty":            "",
+		"truncated":        "d8799f41",
+		"trailing bytes":   "d8798000",
+		"text string":      "6161",
+		"unknown tag":      "d90100 80",
+		"oversized length": "9b7fffffffffffffff",
+		"bad chunk":        "5f6161ff",
+		"indefinite uint":  "1f",
+		"indefinite nint":  "3f",
+		"indefinite tag":   "df",
+		"stray break":      "ff",
+	}
+	for name, input := range cases {
+		input := strings.ReplaceAll(input, " ", "")
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := DecodeHex(input)
+			assert.NotNil(t, err)
+		})
+	}
+
+	t.Run("deep nesting", func(t *testing.T) {
+		t.Parallel()
+
+		input := strings.Repeat("81", maxDepth+2) + "00"
+		_, err := DecodeHex(input)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestEncode_Defaults(t *testing.T) {
+	long := make([]byte, 65)
+	bignum, _ := new(big.Int).SetString("18446744073709551616", 10)
+	cases := map[string]struct {
+		Node Data
+		Want string
+	}{
+		"unit":        {NewConstr(0), "d87980"},
+		"fields":      {NewConstr(1, NewInt64(1), NewBytes([]byte{1})), "d87a9f014101ff"},
+		"constr 7":    {NewConstr(7), "d9050080"},
+		"constr 200":  {NewConstr(200), "d8668218c880"},
+		"empty list":  {NewList(), "80"},
+		"map":         {NewMap(Pair{NewInt64(1), NewInt64(-2)}), "a10121"},
+		"bignum":      {NewInteger(bignum), "c249010000000000000000"},
+		"long bytes":  {NewBytes(long), "5f5840" + strings.Repeat("00", 64) + "4100ff"},
+		"short bytes": {NewBytes(long[:64]), "5840" + strings.Repeat("00", 64)},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := EncodeHex(tc.Node)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.Want, got)
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	node, err := DecodeHex("d8799f4102a1010280c249010000000000000000ff")
+	assert.Nil(t, err)
+
+	data, err := json.Marshal(node)
+	assert.Nil(t, err)
+	want := `{"constructor":0,"fields":[{"bytes":"02"},{"map":[{"k":{"int":1},"v":{"int":2}}]},{"list":[]},{"int":18446744073709551616}]}`
+	assert.Equal(t, want, string(data))
+
+	parsed, err := ParseJSON(data)
+	assert.Nil(t, err)
+	again, err := json.Marshal(parsed)
+	assert.Nil(t, err)
+	assert.Equal(t, want, string(again))
+
+	_, err = ParseJSON([]byte(`{"unknown":1}`))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,199 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package plutusdata
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// The JSON methods below use the "detailed schema" shared by cardano-cli,
+// ogmios and Kupo:
+//
+//	{"constructor": 0, "fields": [...]}
+//	{"map": [{"k": ..., "v": ...}]}
+//	{"list": [...]}
+//	{"int": 42}
+//	{"bytes": "cafe"}
+
+func (c Constr) MarshalJSON() ([]byte, error) {
+	fields := c.Fields
+	if fields == nil {
+		fields = []Data{}
+	}
+	return json.Marshal(struct {
+		Constructor uint64 `json:"constructor"`
+		Fields      []Data `json:"fields"`
+	}{c.Index, fields})
+}
+
+func (m Map) MarshalJSON() ([]byte, error) {
+	type entry struct {
+		K Data `json:"k"`
+		V Data `json:"v"`
+	}
+	entries := make([]entry, 0, len(m.Pairs))
+	for _, pair := range m.Pairs {
+		entries = append(entries, entry{K: pair.Key, V: pair.Value})
+	}
+	return json.Marshal(struct {
+		Map []entry `json:"map"`
+	}{entries})
+}
+
+func (l List) MarshalJSON() ([]byte, error) {
+	items := l.Items
+	if items == nil {
+		items = []Data{}
+	}
+	return json.Marshal(struct {
+		List []Data `json:"list"`
+	}{items})
+}
+
+func (i Integer) MarshalJSON() ([]byte, error) {
+	value := i.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	// big.Int marshals as a bare number, so no precision is lost
+	return json.Marshal(struct {
+		Int *big.Int `json:"int"`
+	}{value})
+}
+
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Bytes string `json:"bytes"`
+	}{hex.EncodeToString(b.Value)})
+}
+
+// ParseJSON parses Plutus data in the detailed schema
+func ParseJSON(data []byte) (Data, error) {
+	return parseJSON(data, 0)
+}
+
+func parseJSON(data []byte, depth int) (Data, error) {
+	if depth > maxDepth {
+		return nil, errors.New("plutus data nested too deeply")
+	}
+
+	var object map[string]json.RawMessage
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&object); err != nil {
+		return nil, fmt.Errorf("invalid plutus data json: %w", err)
+	}
+	if object == nil {
+		return nil, errors.New("invalid plutus data json: expected an object")
+	}
+
+	if raw, ok := object["constructor"]; ok {
+		var index uint64
+		if err := json.Unmarshal(raw, &index); err != nil {
+			return nil, fmt.Errorf("invalid constructor index: %w", err)
+		}
+		fields, err := parseJSONList(object["fields"], depth)
+		if err != nil {
+			return nil, fmt.Errorf("invalid constructor fields: %w", err)
+		}
+		return Constr{Index: index, Fields: fields}, nil
+	}
+
+	if raw, ok := object["map"]; ok {
+		var entries []struct {
+			K json.RawMessage `json:"k"`
+			V json.RawMessage `json:"v"`
+		}
+		if err := json.Unmarshal(raw, &entries); err != nil {
+			return nil, fmt.Errorf("invalid map: %w", err)
+		}
+		var pairs []Pair
+		for _, entry := range entries {
+			key, err := parseJSON(entry.K, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			value, err := parseJSON(entry.V, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, Pair{Key: key, Value: value})
+		}
+		return Map{Pairs: pairs}, nil
+	}
+
+	if raw, ok := object["list"]; ok {
+		items, err := parseJSONList(raw, depth)
+		if err != nil {
+			return nil, fmt.Errorf("invalid list: %w", err)
+		}
+		return List{Items: items}, nil
+	}
+
+	if raw, ok := object["int"]; ok {
+		value, ok := new(big.Int).SetString(string(bytes.TrimSpace(raw)), 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid int: %s", raw)
+		}
+		return Integer{Value: value}, nil
+	}
+
+	if raw, ok := object["bytes"]; ok {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, fmt.Errorf("invalid bytes: %w", err)
+		}
+		value, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bytes: %w", err)
+		}
+		return Bytes{Value: value}, nil
+	}
+
+	return nil, errors.New("invalid plutus data json: unknown schema")
+}
+
+func parseJSONList(raw json.RawMessage, depth int) ([]Data, error) {
+	if raw == nil {
+		return nil, errors.New("missing array")
+	}
+	var elements []json.RawMessage
+	if err := json.Unmarshal(raw, &elements); err != nil {
+		return nil, err
+	}
+	var items []Data
+	for _, element := range elements {
+		item, err := parseJSON(element, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,376 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package plutusdata
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Unmarshaler is implemented by types that decode themselves from Plutus
+// data, e.g. sum types whose variant depends on the constructor index
+type Unmarshaler interface {
+	UnmarshalPlutus(node Data) error
+}
+
+var (
+	bigIntType      = reflect.TypeFor[big.Int]()
+	dataType        = reflect.TypeFor[Data]()
+	unmarshalerType = reflect.TypeFor[Unmarshaler]()
+)
+
+// Unmarshal maps a Plutus data tree onto v, which must be a non-nil
+// pointer. Go types correspond to Plutus data as follows:
+//
+//   - structs are constructors; exported fields are filled in declaration
+//     order, and a blank field tagged `plutus:"constr=N"` requires index N
+//     (fields tagged `plutus:"-"` are skipped)
+//   - pointers are Maybe values: Constr 0 [x] is Just x, Constr 1 [] is
+//     Nothing, which leaves the pointer nil
+//   - bool is Constr 0 [] (False) or Constr 1 [] (True)
+//   - big.Int, *big.Int and the integer kinds are integers, with range
+//     checks; a Maybe integer is therefore **big.Int
+//   - []byte and [N]byte are byte strings; string receives them hex encoded
+//   - slices and arrays are lists, and maps are maps
+//   - Data receives the node as is, and Unmarshaler decodes itself
+func Unmarshal(node Data, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf(
+			"unable to unmarshal plutus data into %T: "+
+				"expected a non-nil pointer",
+			v,
+		)
+	}
+	return unmarshal(node, rv.Elem(), "$")
+}
+
+// UnmarshalHex decodes hex encoded CBOR, as returned by kupo, into v
+func UnmarshalHex(s string, v any) error {
+	node, err := DecodeHex(s)
+	if err != nil {
+		return err
+	}
+	return Unmarshal(node, v)
+}
+
+// constrIndex parses the `plutus:"constr=N"` tag of a struct, if any
+func constrIndex(t reflect.Type) (uint64, bool, error) {
+	for i := 0; i < t.NumField(); i++ {
+		tag, ok := t.Field(i).Tag.Lookup("plutus")
+		if !ok || !strings.HasPrefix(tag, "constr=") {
+			continue
+		}
+		index, err := strconv.ParseUint(
+			strings.TrimPrefix(tag, "constr="),
+			10,
+			64,
+		)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid plutus tag %q on %v", tag, t)
+		}
+		return index, true, nil
+	}
+	return 0, false, nil
+}
+
+func mismatch(path string, want string, node Data) error {
+	return fmt.Errorf("%v: expected %v, got %v", path, want, describe(node))
+}
+
+func describe(node Data) string {
+	switch n := node.(type) {
+	case Constr:
+		return fmt.Sprintf(
+			"constructor %v with %v fields",
+			n.Index,
+			len(n.Fields),
+		)
+	case Map:
+		return "map"
+	case List:
+		return "list"
+	case Integer:
+		return "integer"
+	case Bytes:
+		return "bytes"
+	default:
+		return fmt.Sprintf("%T", node)
+	}
+}
+
+func unmarshal(node Data, rv reflect.Value, path string) error {
+	if rv.CanAddr() && rv.Addr().Type().Implements(unmarshalerType) {
+		unmarshaler := rv.Addr().Interface().(Unmarshaler)
+		if err := unmarshaler.UnmarshalPlutus(node); err != nil {
+			return fmt.Errorf("%v: %w", path, err)
+		}
+		return nil
+	}
+	if rv.Type() == dataType {
+		rv.Set(reflect.ValueOf(node))
+		return nil
+	}
+	if rv.Type() == bigIntType || rv.Type() == reflect.PointerTo(bigIntType) {
+		n, ok := node.(Integer)
+		if !ok {
+			return mismatch(path, "integer", node)
+		}
+		value := new(big.Int)
+		if n.Value != nil {
+			value.Set(n.Value)
+		}
+		if rv.Kind() == reflect.Pointer {
+			rv.Set(reflect.ValueOf(value))
+		} else {
+			rv.Set(reflect.ValueOf(value).Elem())
+		}
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer:
+		c, ok := node.(Constr)
+		switch {
+		case ok && c.Index == 1 && len(c.Fields) == 0:
+			rv.Set(reflect.Zero(rv.Type()))
+			return nil
+		case ok && c.Index == 0 && len(c.Fields) == 1:
+			value := reflect.New(rv.Type().Elem())
+			err := unmarshal(c.Fields[0], value.Elem(), path+".Just")
+			if err != nil {
+				return err
+			}
+			rv.Set(value)
+			return nil
+		default:
+			return mismatch(path, "Maybe (Just or Nothing)", node)
+		}
+
+	case reflect.Bool:
+		c, ok := node.(Constr)
+		if !ok || c.Index > 1 || len(c.Fields) != 0 {
+			return mismatch(path, "Bool (False or True)", node)
+		}
+		rv.SetBool(c.Index == 1)
+		return nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := node.(Integer)
+		if !ok {
+			return mismatch(path, "integer", node)
+		}
+		if n.Value == nil {
+			rv.SetInt(0)
+			return nil
+		}
+		if !n.Value.IsInt64() || rv.OverflowInt(n.Value.Int64()) {
+			return fmt.Errorf(
+				"%v: integer %v overflows %v",
+				path,
+				n.Value,
+				rv.Type(),
+			)
+		}
+		rv.SetInt(n.Value.Int64())
+		return nil
+
+	case reflect.Uint,
+		reflect.Uint8,
+		reflect.Uint16,
+		reflect.Uint32,
+		reflect.Uint64:
+		n, ok := node.(Integer)
+		if !ok {
+			return mismatch(path, "integer", node)
+		}
+		if n.Value == nil {
+			rv.SetUint(0)
+			return nil
+		}
+		if n.Value.Sign() < 0 || !n.Value.IsUint64() ||
+			rv.OverflowUint(n.Value.Uint64()) {
+			return fmt.Errorf(
+				"%v: integer %v overflows %v",
+				path,
+				n.Value,
+				rv.Type(),
+			)
+		}
+		rv.SetUint(n.Value.Uint64())
+		return nil
+
+	case reflect.String:
+		b, ok := node.(Bytes)
+		if !ok {
+			return mismatch(path, "bytes", node)
+		}
+		rv.SetString(hex.EncodeToString(b.Value))
+		return nil
+
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			b, ok := node.(Bytes)
+			if !ok {
+				return mismatch(path, "bytes", node)
+			}
+			rv.SetBytes(append([]byte{}, b.Value...))
+			return nil
+		}
+		l, ok := node.(List)
+		if !ok {
+			return mismatch(path, "list", node)
+		}
+		slice := reflect.MakeSlice(rv.Type(), len(l.Items), len(l.Items))
+		for i, item := range l.Items {
+			itemPath := fmt.Sprintf("%v[%v]", path, i)
+			if err := unmarshal(item, slice.Index(i), itemPath); err != nil {
+				return err
+			}
+		}
+		rv.Set(slice)
+		return nil
+
+	case reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			b, ok := node.(Bytes)
+			if !ok {
+				return mismatch(path, "bytes", node)
+			}
+			if len(b.Value) != rv.Len() {
+				return fmt.Errorf(
+					"%v: expected %v bytes, got %v",
+					path,
+					rv.Len(),
+					len(b.Value),
+				)
+			}
+			reflect.Copy(rv, reflect.ValueOf(b.Value))
+			return nil
+		}
+		l, ok := node.(List)
+		if !ok {
+			return mismatch(path, "list", node)
+		}
+		if len(l.Items) != rv.Len() {
+			return fmt.Errorf(
+				"%v: expected %v items, got %v",
+				path,
+				rv.Len(),
+				len(l.Items),
+			)
+		}
+		for i, item := range l.Items {
+			itemPath := fmt.Sprintf("%v[%v]", path, i)
+			if err := unmarshal(item, rv.Index(i), itemPath); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Map:
+		m, ok := node.(Map)
+		if !ok {
+			return mismatch(path, "map", node)
+		}
+		out := reflect.MakeMapWithSize(rv.Type(), len(m.Pairs))
+		for i, pair := range m.Pairs {
+			key := reflect.New(rv.Type().Key()).Elem()
+			keyPath := fmt.Sprintf("%v.keys[%v]", path, i)
+			if err := unmarshal(pair.Key, key, keyPath); err != nil {
+				return err
+			}
+			value := reflect.New(rv.Type().Elem()).Elem()
+			valuePath := fmt.Sprintf("%v.values[%v]", path, i)
+			if err := unmarshal(pair.Value, value, valuePath); err != nil {
+				return err
+			}
+			out.SetMapIndex(key, value)
+		}
+		rv.Set(out)
+		return nil
+
+	case reflect.Struct:
+		return unmarshalStruct(node, rv, path)
+
+	default:
+		return fmt.Errorf(
+			"%v: unable to unmarshal plutus data into %v",
+			path,
+			rv.Type(),
+		)
+	}
+}
+
+func unmarshalStruct(node Data, rv reflect.Value, path string) error {
+	t := rv.Type()
+	c, ok := node.(Constr)
+	if !ok {
+		return mismatch(path, "constructor", node)
+	}
+	index, tagged, err := constrIndex(t)
+	if err != nil {
+		return err
+	}
+	if tagged && c.Index != index {
+		return fmt.Errorf(
+			"%v: expected constructor %v for %v, got %v",
+			path,
+			index,
+			t,
+			c.Index,
+		)
+	}
+
+	var fields []int
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || field.Tag.Get("plutus") == "-" {
+			continue
+		}
+		fields = append(fields, i)
+	}
+	if len(fields) != len(c.Fields) {
+		return fmt.Errorf(
+			"%v: %v has %v fields, but constructor %v has %v",
+			path,
+			t,
+			len(fields),
+			c.Index,
+			len(c.Fields),
+		)
+	}
+	for i, field := range fields {
+		fieldPath := path + "." + t.Field(field).Name
+		err := unmarshal(c.Fields[i], rv.Field(field), fieldPath)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package plutusdata
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+type testCredential struct {
+	_   struct{} `plutus:"constr=0"`
+	Key [4]byte
+}
+
+type testDestination struct {
+	_       struct{} `plutus:"constr=0"`
+	Payment testCredential
+	Stake   *testCredential
+}
+
+type testSwap struct {
+	_        struct{} `plutus:"constr=1"`
+	Offer    *big.Int
+	MinTaken uint64
+}
+
+type testOrder struct {
+	_           struct{} `plutus:"constr=0"`
+	Pool        *string
+	Owner       []byte
+	Fee         big.Int
+	Destination testDestination
+	Details     testSwap
+	Extensions  Data
+	Flags       []bool
+	Labels      map[string]int
+	internal    int
+}
+
+func TestUnmarshal(t *testing.T) {
+	offer, _ := new(big.Int).SetString("100000000000000000000", 10)
+	node := NewConstr(0,
+		NewConstr(0, NewBytes([]byte{0xca, 0xfe})),
+		NewBytes([]byte{1, 2, 3}),
+		NewInt64(2_500_000),
+		NewConstr(0,
+			NewConstr(0, NewBytes([]byte{1, 2, 3, 4})),
+			NewConstr(1),
+		),
+		NewConstr(1, NewInteger(offer), NewInt64(42)),
+		NewList(NewInt64(7)),
+		NewList(NewConstr(1), NewConstr(0)),
+		NewMap(Pair{NewBytes([]byte{0xab}), NewInt64(-3)}),
+	)
+	raw, err := EncodeHex(node)
+	assert.Nil(t, err)
+
+	var order testOrder
+	err = UnmarshalHex(raw, &order)
+	assert.Nil(t, err)
+	assert.NotNil(t, order.Pool)
+	assert.Equal(t, "cafe", *order.Pool)
+	assert.Equal(t, []byte{1, 2, 3}, order.Owner)
+	assert.Equal(t, "2500000", order.Fee.String())
+	assert.Equal(t, [4]byte{1, 2, 3, 4}, order.Destination.Payment.Key)
+	assert.Nil(t, order.Destination.Stake)
+	assert.Equal(t, offer, order.Details.Offer)
+	assert.EqualValues(t, 42, order.Details.MinTaken)
+	extensions, err := EncodeHex(order.Extensions)
+	assert.Nil(t, err)
+	assert.Equal(t, "9f07ff", extensions)
+	assert.Equal(t, []bool{true, false}, order.Flags)
+	assert.Equal(t, map[string]int{"ab": -3}, order.Labels)
+}
+
+type testVariant struct {
+	Index uint64
+}
+
+func (v *testVariant) UnmarshalPlutus(node Data) error {
+	v.Index = node.(Constr).Index
+	return nil
+}
+
+func TestUnmarshal_Unmarshaler(t *testing.T) {
+	var v []testVariant
+	err := Unmarshal(NewList(NewConstr(3), NewConstr(5)), &v)
+	assert.Nil(t, err)
+	assert.Equal(t, []testVariant{{3}, {5}}, v)
+}
+
+func TestUnmarshal_Errors(t *testing.T) {
+	t.Run("wrong constructor", func(t *testing.T) {
+		t.Parallel()
+
+		var swap testSwap
+		err := Unmarshal(NewConstr(0, NewInt64(1), NewInt64(2)), &swap)
+		assert.Contains(t, err.Error(), "expected constructor 1")
+	})
+
+	t.Run("wrong field count", func(t *testing.T) {
+		t.Parallel()
+
+		var swap testSwap
+		err := Unmarshal(NewConstr(1, NewInt64(1)), &swap)
+		assert.Contains(t, err.Error(), "has 2 fields")
+	})
+
+	t.Run("path to nested mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		var destination testDestination
+		err := Unmarshal(
+			NewConstr(0, NewConstr(0, NewInt64(1)), NewConstr(1)),
+			&destination,
+		)
+		assert.Contains(
+			t,
+			err.Error(),
+			"$.Payment.Key: expected bytes, got integer",
+		)
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		t.Parallel()
+
+		var v uint8
+		err := Unmarshal(NewInt64(256), &v)
+		assert.Contains(t, err.Error(), "overflows")
+		err = Unmarshal(NewInt64(-1), &v)
+		assert.Contains(t, err.Error(), "overflows")
+	})
+
+	t.Run("not a pointer", func(t *testing.T) {
+		t.Parallel()
+
+		var v int
+		err := Unmarshal(NewInt64(1), v)
+		assert.NotNil(t, err)
+	})
+}
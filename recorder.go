@@ -0,0 +1,329 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// fixture is a recorded exchange with kupo; request bodies that are JSON are
+// kept as is, so fixtures are easy to read and diff. The response body is
+// saved verbatim alongside, in the file named by bodyPath.
+type fixture struct {
+	Request  fixtureRequest  `json:"request"`
+	Response fixtureResponse `json:"response"`
+}
+
+type fixtureRequest struct {
+	Method string          `json:"method"`
+	URL    string          `json:"url"` // path and query, without the host
+	Body   json.RawMessage `json:"body,omitempty"`
+	Text   string          `json:"body_text,omitempty"`
+}
+
+type fixtureResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+}
+
+// setBody stores body in whichever field keeps it readable
+func setBody(body []byte, raw *json.RawMessage, text *string) {
+	if len(body) == 0 {
+		return
+	}
+	if json.Valid(body) {
+		*raw = append(json.RawMessage{}, body...)
+	} else {
+		*text = string(body)
+	}
+}
+
+// fixtureKey names the fixtures for a request: a readable slug of the method
+// and path, and a digest of everything that identifies the request
+func fixtureKey(method, url string, body []byte) string {
+	digest := sha256.New()
+	digest.Write([]byte(method + " " + url + "\n"))
+	digest.Write(body)
+
+	slug := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.ToLower(method)+url)
+	if len(slug) > 64 {
+		slug = slug[:64]
+	}
+	return slug + "-" + hex.EncodeToString(digest.Sum(nil))[:16]
+}
+
+// fixturePath is the file holding the nth recording of a request; repeated
+// requests, e.g. when polling, are recorded in sequence
+func fixturePath(dir, key string, n int) string {
+	return filepath.Join(dir, fmt.Sprintf("%v.%v.json", key, n))
+}
+
+// bodyPath is the file holding the response body of the nth recording
+func bodyPath(dir, key string, n int) string {
+	return filepath.Join(dir, fmt.Sprintf("%v.%v.body", key, n))
+}
+
+// readRequest returns the request's URL without the host, and its body,
+// leaving the body readable for the next transport
+func readRequest(req *http.Request) (string, []byte, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return "", nil, fmt.Errorf("unable to read request body: %w", err)
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return req.URL.RequestURI(), body, nil
+}
+
+// recordingTransport saves each exchange as a fixture
+type recordingTransport struct {
+	dir  string
+	next http.RoundTripper
+
+	mutex  sync.Mutex
+	counts map[string]int
+}
+
+func newRecordingTransport(
+	dir string,
+	next http.RoundTripper,
+) *recordingTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &recordingTransport{dir: dir, next: next, counts: map[string]int{}}
+}
+
+func (t *recordingTransport) RoundTrip(
+	req *http.Request,
+) (*http.Response, error) {
+	url, reqBody, err := readRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	key := fixtureKey(req.Method, url, reqBody)
+	n, err := t.sequence(key)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	f := fixture{
+		Request: fixtureRequest{Method: req.Method, URL: url},
+		Response: fixtureResponse{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header.Clone(),
+		},
+	}
+	// These vary between runs without changing the meaning of the response
+	f.Response.Header.Del("Date")
+	f.Response.Header.Del("Content-Length")
+	setBody(reqBody, &f.Request.Body, &f.Request.Text)
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unable to encode fixture: %w", err)
+	}
+	err = os.WriteFile(fixturePath(t.dir, key, n), data, 0o644)
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unable to write fixture: %w", err)
+	}
+	file, err := os.Create(bodyPath(t.dir, key, n))
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unable to write fixture: %w", err)
+	}
+	resp.Body = &recordingBody{body: resp.Body, file: file}
+	return resp, nil
+}
+
+// sequence numbers the next recording of key; recordings left in the directory
+// by an earlier run are deleted first, so replay doesn't serve stale ones
+// after the new sequence
+func (t *recordingTransport) sequence(key string) (int, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	n, ok := t.counts[key]
+	if !ok {
+		if err := os.MkdirAll(t.dir, 0o755); err != nil {
+			return 0, fmt.Errorf(
+				"unable to create fixtures directory: %w",
+				err,
+			)
+		}
+		stale, err := filepath.Glob(filepath.Join(t.dir, key+".*"))
+		if err != nil {
+			return 0, fmt.Errorf("unable to list fixtures: %w", err)
+		}
+		for _, path := range stale {
+			if err := os.Remove(path); err != nil {
+				return 0, fmt.Errorf("unable to remove fixture: %w", err)
+			}
+		}
+	}
+	t.counts[key] = n + 1
+	return n, nil
+}
+
+// recordingBody copies the response body to its fixture as the caller reads
+// it, so streamed responses are still streamed. Closing it reads whatever
+// the caller left, so the fixture holds the whole response.
+type recordingBody struct {
+	body io.ReadCloser
+	file *os.File
+	err  error // the first error writing the fixture
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if n > 0 && b.err == nil {
+		_, b.err = b.file.Write(p[:n])
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	if b.err == nil {
+		_, b.err = io.Copy(b.file, b.body)
+	}
+	b.body.Close()
+	if err := b.file.Close(); b.err == nil {
+		b.err = err
+	}
+	if b.err != nil {
+		return fmt.Errorf("unable to write fixture: %w", b.err)
+	}
+	return nil
+}
+
+// ReplayTransport serves responses recorded with WithRecorder, without
+// contacting kupo. Repeated requests are answered with their recordings in
+// order, and then with the last recording.
+type ReplayTransport struct {
+	dir string
+
+	mutex  sync.Mutex
+	counts map[string]int
+}
+
+// NewReplayTransport serves the fixtures in dir; use it with WithTransport
+func NewReplayTransport(dir string) *ReplayTransport {
+	return &ReplayTransport{dir: dir, counts: map[string]int{}}
+}
+
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	url, reqBody, err := readRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	key := fixtureKey(req.Method, url, reqBody)
+
+	t.mutex.Lock()
+	n := t.counts[key]
+	t.counts[key]++
+	t.mutex.Unlock()
+
+	data, err := os.ReadFile(fixturePath(t.dir, key, n))
+	for errors.Is(err, fs.ErrNotExist) && n > 0 {
+		n--
+		data, err = os.ReadFile(fixturePath(t.dir, key, n))
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf(
+			"no fixture recorded for %v %v in %v",
+			req.Method,
+			url,
+			t.dir,
+		)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read fixture: %w", err)
+	}
+
+	var f fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf(
+			"unable to parse fixture %v: %w",
+			fixturePath(t.dir, key, n),
+			err,
+		)
+	}
+	body, err := os.Open(bodyPath(t.dir, key, n))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read fixture: %w", err)
+	}
+	info, err := body.Stat()
+	if err != nil {
+		body.Close()
+		return nil, fmt.Errorf("unable to read fixture: %w", err)
+	}
+	header := f.Response.Header
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status: fmt.Sprintf(
+			"%v %v",
+			f.Response.StatusCode,
+			http.StatusText(f.Response.StatusCode),
+		),
+		StatusCode:    f.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          body,
+		ContentLength: info.Size(),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,204 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SundaeSwap-finance/kugo"
+	"github.com/SundaeSwap-finance/kugo/kugotest"
+	"github.com/tj/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	fake := kugotest.New().
+		RollForward(kugo.Point{SlotNo: 10, HeaderHash: "aa"}).
+		AddDatum("aaaa", "d87980")
+	server := fake.HTTP()
+
+	ctx := context.Background()
+	recorder := kugo.New(kugo.WithEndpoint(server.URL), kugo.WithRecorder(dir))
+	datum, err := recorder.Datum(ctx, "aaaa")
+	assert.Nil(t, err)
+	assert.Equal(t, "d87980", datum)
+	patterns, err := recorder.AddPattern(
+		ctx,
+		kugo.AnyPattern(),
+		kugo.RollbackTo(kugo.Point{SlotNo: 10, HeaderHash: "aa"}),
+	)
+	assert.Nil(t, err)
+	points, err := recorder.Checkpoints(ctx)
+	assert.Nil(t, err)
+	// A later poll sees a new checkpoint
+	fake.RollForward(kugo.Point{SlotNo: 20, HeaderHash: "bb"})
+	polled, err := recorder.Checkpoints(ctx)
+	assert.Nil(t, err)
+	server.Close()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	assert.Nil(t, err)
+	assert.Len(t, files, 4)
+
+	replay := kugo.New(
+		kugo.WithEndpoint("http://kupo.invalid"),
+		kugo.WithTransport(kugo.NewReplayTransport(dir)),
+	)
+	got, err := replay.Datum(ctx, "aaaa")
+	assert.Nil(t, err)
+	assert.Equal(t, datum, got)
+
+	gotPatterns, err := replay.AddPattern(
+		ctx,
+		kugo.AnyPattern(),
+		kugo.RollbackTo(kugo.Point{SlotNo: 10, HeaderHash: "aa"}),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, patterns, gotPatterns)
+
+	gotPoints, err := replay.Checkpoints(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, points, gotPoints)
+	for i := 0; i < 2; i++ {
+		// The last recording is repeated once the sequence is exhausted
+		gotPoints, err = replay.Checkpoints(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, polled, gotPoints)
+	}
+
+	// A request with a different body was never recorded
+	_, err = replay.AddPattern(
+		ctx,
+		kugo.AnyPattern(),
+		kugo.RollbackTo(kugo.Point{SlotNo: 20, HeaderHash: "bb"}),
+	)
+	assert.NotNil(t, err)
+	assert.Contains(
+		t,
+		err.Error(),
+		"no fixture recorded for PUT /v1/patterns/*",
+	)
+}
+
+func TestRecorder_RecordsErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	server := kugotest.New().SetPatterns(address).HTTP()
+	ctx := context.Background()
+	other := kugo.Pattern(
+		"addr1w8phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gtcyjy7wx",
+	)
+
+	_, err := kugo.New(
+		kugo.WithEndpoint(server.URL),
+		kugo.WithRecorder(dir),
+	).Matches(ctx, other)
+	assert.True(t, errors.Is(err, kugo.ErrPatternNotIndexed))
+	server.Close()
+
+	_, err = kugo.New(
+		kugo.WithTransport(kugo.NewReplayTransport(dir)),
+	).Matches(ctx, other)
+	assert.True(t, errors.Is(err, kugo.ErrPatternNotIndexed))
+}
+
+func TestRecorder_Streams(t *testing.T) {
+	t.Parallel()
+
+	// kupo sends the first match, then waits until the client has read it
+	read := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`[{"transaction_id":"tx1"},`))
+			w.(http.Flusher).Flush()
+			select {
+			case <-read:
+			case <-r.Context().Done():
+				return
+			}
+			_, _ = w.Write([]byte(`{"transaction_id":"tx2"}]`))
+		},
+	))
+	defer server.Close()
+	dir := t.TempDir()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	recorder := kugo.New(kugo.WithEndpoint(server.URL), kugo.WithRecorder(dir))
+	for match, err := range recorder.MatchesStream(ctx, kugo.Pattern("*")) {
+		assert.Nil(t, err)
+		assert.Equal(t, "tx1", match.TransactionID)
+		close(read)
+		// Stopping early still records the whole response
+		break
+	}
+
+	replay := kugo.New(kugo.WithTransport(kugo.NewReplayTransport(dir)))
+	matches, err := replay.Matches(ctx, kugo.Pattern("*"))
+	assert.Nil(t, err)
+	assert.Len(t, matches, 2)
+}
+
+func TestRecorder_ReplacesStaleFixtures(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	fake := kugotest.New().
+		RollForward(kugo.Point{SlotNo: 10, HeaderHash: "aa"})
+	server := fake.HTTP()
+	defer server.Close()
+	ctx := context.Background()
+
+	// An earlier run polled twice
+	first := kugo.New(kugo.WithEndpoint(server.URL), kugo.WithRecorder(dir))
+	for i := 0; i < 2; i++ {
+		_, err := first.Checkpoints(ctx)
+		assert.Nil(t, err)
+	}
+
+	fake.RollBackward(0).RollForward(kugo.Point{SlotNo: 20, HeaderHash: "bb"})
+	points, err := kugo.New(kugo.WithEndpoint(server.URL), kugo.WithRecorder(dir)).
+		Checkpoints(ctx)
+	assert.Nil(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	assert.Nil(t, err)
+	assert.Len(t, files, 2)
+	replay := kugo.New(kugo.WithTransport(kugo.NewReplayTransport(dir)))
+	for i := 0; i < 2; i++ {
+		got, err := replay.Checkpoints(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, points, got)
+	}
+}
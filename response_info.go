@@ -0,0 +1,90 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// ResponseInfo carries the metadata kupo attaches to each response
+type ResponseInfo struct {
+	// MostRecentCheckpoint is the slot of the most recent block kupo had
+	// indexed when it answered, i.e. the point results are consistent with
+	MostRecentCheckpoint uint64
+	// HasCheckpoint is false if kupo didn't report a checkpoint
+	HasCheckpoint bool
+}
+
+type responseInfoKey struct{}
+
+// RecordResponseInfo returns a context which, when passed to any Client
+// method, records the metadata of the response in info:
+//
+//	var info kugo.ResponseInfo
+//	matches, err := client.Matches(kugo.RecordResponseInfo(ctx, &info), ...)
+//	fmt.Println(info.MostRecentCheckpoint)
+//
+// When one context is shared between several calls, including concurrent
+// ones such as those made by ResolveDatums, info reflects the most recent
+// response; read it once the calls have returned. Calls answered from a
+// cache, without asking kupo, leave info unchanged, as do Datum and Script
+// on a client using WithDatumCache or WithScriptCache, whose requests are
+// shared between concurrent callers.
+func RecordResponseInfo(
+	ctx context.Context,
+	info *ResponseInfo,
+) context.Context {
+	return context.WithValue(
+		ctx,
+		responseInfoKey{},
+		&responseInfoRecorder{info: info},
+	)
+}
+
+// responseInfoRecorder serializes the writes of concurrent calls sharing a
+// context
+type responseInfoRecorder struct {
+	mutex sync.Mutex
+	info  *ResponseInfo
+}
+
+func recordResponseInfo(ctx context.Context, resp *http.Response) {
+	recorder, ok := ctx.Value(responseInfoKey{}).(*responseInfoRecorder)
+	if !ok || recorder == nil || recorder.info == nil {
+		return
+	}
+	info := ResponseInfo{}
+	header := resp.Header.Get("X-Most-Recent-Checkpoint")
+	if slot, err := strconv.ParseUint(header, 10, 64); err == nil {
+		info.MostRecentCheckpoint = slot
+		info.HasCheckpoint = true
+	}
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	*recorder.info = info
+}
@@ -0,0 +1,91 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/SundaeSwap-finance/kugo"
+	"github.com/SundaeSwap-finance/kugo/kugotest"
+	"github.com/tj/assert"
+)
+
+func Test_RecordResponseInfo(t *testing.T) {
+	t.Parallel()
+	const hash = "34215ad90b1ade84f5b4fe3c0a16cb3afeae468210535e0305efd93931f35059"
+	server := kugotest.New().
+		RollForward(kugo.Point{SlotNo: 1234, HeaderHash: "aa"}).
+		AddDatum(hash, "d87980").
+		HTTP()
+	defer server.Close()
+
+	c := kugo.New(kugo.WithEndpoint(server.URL))
+
+	var info kugo.ResponseInfo
+	ctx := kugo.RecordResponseInfo(context.Background(), &info)
+	_, err := c.Datum(ctx, hash)
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		kugo.ResponseInfo{MostRecentCheckpoint: 1234, HasCheckpoint: true},
+		info,
+	)
+
+	_, err = c.Metadata(ctx, 1, "")
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1234, info.MostRecentCheckpoint)
+
+	// Error responses carry the header too
+	info = kugo.ResponseInfo{}
+	_, err = c.Matches(ctx, kugo.Pattern("unknown"))
+	assert.NotNil(t, err)
+	assert.EqualValues(t, 1234, info.MostRecentCheckpoint)
+}
+
+func Test_RecordResponseInfoConcurrently(t *testing.T) {
+	t.Parallel()
+	fake := kugotest.New().
+		RollForward(kugo.Point{SlotNo: 1234, HeaderHash: "aa"})
+	matches := make([]kugo.Match, 20)
+	for i := range matches {
+		hash := fmt.Sprintf("%064x", i)
+		fake.AddDatum(hash, "d87980")
+		matches[i] = kugo.Match{DatumHash: hash}
+	}
+	server := fake.HTTP()
+	defer server.Close()
+
+	c := kugo.New(kugo.WithEndpoint(server.URL))
+
+	var info kugo.ResponseInfo
+	ctx := kugo.RecordResponseInfo(context.Background(), &info)
+	assert.Nil(t, kugo.ResolveDatums(ctx, c, matches, 8))
+	assert.Equal(
+		t,
+		kugo.ResponseInfo{MostRecentCheckpoint: 1234, HasCheckpoint: true},
+		info,
+	)
+}
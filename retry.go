@@ -0,0 +1,139 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how idempotent requests are retried when kupo is
+// unreachable, still starting up, or shedding load
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts, including any delay kupo
+	// asks for with Retry-After
+	MaxBackoff time.Duration
+	// Multiplier grows the delay after each attempt
+	Multiplier float64
+	// Jitter randomly shortens each delay by up to this fraction, so that
+	// many clients don't retry in lockstep
+	Jitter float64
+}
+
+// DefaultRetryPolicy makes up to 5 attempts, backing off from 100ms to 5s
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// withDefaults fills in any unset fields from DefaultRetryPolicy
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	d := DefaultRetryPolicy()
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = d.MaxAttempts
+	}
+	if p.InitialBackoff == 0 {
+		p.InitialBackoff = d.InitialBackoff
+	}
+	if p.MaxBackoff == 0 {
+		p.MaxBackoff = d.MaxBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = d.Multiplier
+	}
+	p.Jitter = min(max(p.Jitter, 0), 1)
+	return p
+}
+
+// backoff returns the delay before the given retry, counting from 1
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(retry-1))
+	delay = min(delay, float64(p.MaxBackoff))
+	delay -= delay * p.Jitter * rand.Float64()
+	return time.Duration(delay)
+}
+
+// isRetryable reports whether a request may succeed if tried again; only
+// transport failures and kupo signalling that it is busy or unavailable
+// qualify, never client errors such as an invalid pattern. A 503 from
+// /health is kupo's answer while disconnected from the node, not a failure,
+// so it's returned right away for callers polling it.
+func isRetryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if err == nil && resp.StatusCode == http.StatusServiceUnavailable &&
+		req.URL.Path == healthPath {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled) &&
+			!errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses the Retry-After header, in either seconds or HTTP date
+// form, capping the delay at limit so a large or hostile value can't stall
+// the caller
+func retryAfter(resp *http.Response, limit time.Duration) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	header := resp.Header.Get("Retry-After")
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseUint(header, 10, 64); err == nil {
+		if seconds > uint64(limit/time.Second) {
+			return limit, true
+		}
+		return min(time.Duration(seconds)*time.Second, limit), true
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return min(max(time.Until(at), 0), limit), true
+	}
+	return 0, false
+}
@@ -0,0 +1,218 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+)
+
+func Test_Retry(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+	}
+
+	t.Run(
+		"Retries until kupo is available",
+		func(t *testing.T) {
+			t.Parallel()
+
+			var requests atomic.Int32
+			server := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if requests.Add(1) < 3 {
+						w.Header().Set("Retry-After", "0")
+						writeError(w, http.StatusServiceUnavailable, "still syncing")
+						return
+					}
+					writeSuccess(w, []string{"*"})
+				}),
+			)
+			defer server.Close()
+
+			c := New(WithEndpoint(server.URL), WithRetry(policy))
+			patterns, err := c.Patterns(context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"*"}, patterns)
+			assert.EqualValues(t, 3, requests.Load())
+		},
+	)
+
+	t.Run(
+		"Caps Retry-After at MaxBackoff",
+		func(t *testing.T) {
+			t.Parallel()
+
+			var requests atomic.Int32
+			server := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if requests.Add(1) < 2 {
+						w.Header().Set("Retry-After", "86400")
+						writeError(w, http.StatusServiceUnavailable, "busy")
+						return
+					}
+					writeSuccess(w, []string{"*"})
+				}),
+			)
+			defer server.Close()
+
+			c := New(WithEndpoint(server.URL), WithRetry(policy))
+			start := time.Now()
+			_, err := c.Patterns(context.Background())
+			assert.Nil(t, err)
+			assert.True(t, time.Since(start) < 10*time.Second)
+			assert.EqualValues(t, 2, requests.Load())
+		},
+	)
+
+	t.Run(
+		"Gives up after max attempts",
+		func(t *testing.T) {
+			t.Parallel()
+
+			var requests atomic.Int32
+			server := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					requests.Add(1)
+					writeError(w, http.StatusTooManyRequests, "slow down")
+				}),
+			)
+			defer server.Close()
+
+			c := New(WithEndpoint(server.URL), WithRetry(policy))
+			_, err := c.Patterns(context.Background())
+			assert.True(t, errors.Is(err, ErrServerBusy))
+			assert.EqualValues(t, 3, requests.Load())
+		},
+	)
+
+	t.Run(
+		"Never retries client errors or writes",
+		func(t *testing.T) {
+			t.Parallel()
+
+			var requests atomic.Int32
+			server := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					requests.Add(1)
+					if r.Method == http.MethodPut {
+						writeError(w, http.StatusServiceUnavailable, "busy")
+						return
+					}
+					writeError(w, http.StatusBadRequest, "invalid pattern")
+				}),
+			)
+			defer server.Close()
+
+			c := New(WithEndpoint(server.URL), WithRetry(policy))
+			_, err := c.Matches(context.Background(), Pattern("abc"))
+			assert.NotNil(t, err)
+			assert.EqualValues(t, 1, requests.Load())
+
+			_, err = c.AddPattern(context.Background(), AnyPattern(), RollbackTo(Point{}))
+			assert.True(t, errors.Is(err, ErrServerBusy))
+			assert.EqualValues(t, 2, requests.Load())
+		},
+	)
+
+	t.Run(
+		"Retries connection failures",
+		func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.NotFoundHandler())
+			endpoint := server.URL
+			server.Close()
+
+			transport := &countingTransport{next: http.DefaultTransport}
+			c := New(
+				WithEndpoint(endpoint),
+				WithTransport(transport),
+				WithRetry(policy),
+			)
+			_, err := c.Patterns(context.Background())
+			assert.NotNil(t, err)
+			assert.EqualValues(t, 3, transport.requests.Load())
+		},
+	)
+}
+
+func Test_RetryPolicy(t *testing.T) {
+	p := RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+	}.withDefaults()
+	assert.Equal(t, 5, p.MaxAttempts)
+	assert.Equal(t, 100*time.Millisecond, p.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, p.backoff(2))
+	assert.Equal(t, 800*time.Millisecond, p.backoff(4))
+	assert.Equal(t, time.Second, p.backoff(10))
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay := p.backoff(1)
+		assert.True(t, delay >= 50*time.Millisecond && delay <= 100*time.Millisecond)
+	}
+}
+
+func Test_RetryAfter(t *testing.T) {
+	limit := 5 * time.Second
+	testCases := map[string]struct {
+		Header string
+		Want   time.Duration
+		OK     bool
+	}{
+		"missing":  {"", 0, false},
+		"invalid":  {"soon", 0, false},
+		"negative": {"-1", 0, false},
+		"seconds":  {"2", 2 * time.Second, true},
+		"capped":   {"3600", limit, true},
+		"overflow": {"99999999999999999999", 0, false},
+		"huge":     {"9223372036854775807", limit, true},
+		"date":     {time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), limit, true},
+		"past":     {"Mon, 02 Jan 2006 15:04:05 GMT", 0, true},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			resp := &http.Response{Header: http.Header{}}
+			if tc.Header != "" {
+				resp.Header.Set("Retry-After", tc.Header)
+			}
+			delay, ok := retryAfter(resp, limit)
+			assert.Equal(t, tc.OK, ok)
+			assert.Equal(t, tc.Want, delay)
+		})
+	}
+}
@@ -24,16 +24,18 @@
 package kugo
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
+	"github.com/SundaeSwap-finance/kugo/address"
 	"github.com/SundaeSwap-finance/ogmigo/v6"
 	"golang.org/x/crypto/blake2b"
 )
@@ -72,6 +74,10 @@ func (s *Script) MarshalJSON() ([]byte, error) {
 }
 
 func (s *Script) UnmarshalJSON(data []byte) error {
+	// kupo reports a missing script as null, e.g. when resolving hashes
+	if string(bytes.TrimSpace(data)) == "null" {
+		return nil
+	}
 	var r struct {
 		Language string
 		Script   string
@@ -96,8 +102,13 @@ func (s *Script) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// Hash returns the script hash; plutus scripts are normalized first, so any
+// CBOR wrapping yields the hash the ledger would compute
 func (s Script) Hash() []byte {
 	scriptBytes, _ := hex.DecodeString(s.Script)
+	if serialized, _, err := s.unwrap(); err == nil {
+		scriptBytes = serialized
+	}
 	blake, err := blake2b.New(224/8, nil)
 	if err != nil {
 		panic(
@@ -122,9 +133,77 @@ func (s Script) Hash() []byte {
 	return hashBytes[:]
 }
 
+// verify checks that the script hashes to the expected hex encoded hash
+func (s Script) verify(expected string) error {
+	actual := hex.EncodeToString(s.Hash())
+	if !strings.EqualFold(actual, expected) {
+		return &IntegrityError{Kind: "script", Expected: expected, Actual: actual}
+	}
+	return nil
+}
+
+// Address returns the bech32 enterprise address locked by the script, e.g.
+// for use with the Address filter
+func (s Script) Address(network address.Network) string {
+	return address.NewEnterprise(network, address.ScriptCredential(s.Hash())).String()
+}
+
 func (c *Client) Script(
 	ctx context.Context,
 	scriptHash string,
+) (*Script, error) {
+	if c.scriptCache == nil {
+		return c.loadScript(ctx, scriptHash)
+	}
+	script, err := cached(
+		ctx,
+		c,
+		c.scriptCache,
+		"script:"+scriptHash,
+		func(ctx context.Context) (Script, bool, error) {
+			script, err := c.loadScript(ctx, scriptHash)
+			if err != nil || script == nil {
+				return Script{}, false, err
+			}
+			return *script, script.Script != "", nil
+		},
+	)
+	if err != nil || script.Script == "" {
+		return nil, err
+	}
+	return &script, nil
+}
+
+// loadScript reads the script from the disk cache, if any, or fetches it
+// from kupo and saves it there; as with datums, only scripts that match
+// their hash are read from, or written to, the disk cache
+func (c *Client) loadScript(
+	ctx context.Context,
+	scriptHash string,
+) (*Script, error) {
+	if disk := c.options.diskCache; disk != nil {
+		if value, ok := disk.get(diskScript, scriptHash); ok {
+			script := &Script{}
+			err := json.Unmarshal(value, script)
+			if err == nil && script.verify(scriptHash) == nil {
+				return script, nil
+			}
+		}
+	}
+
+	script, err := c.fetchScript(ctx, scriptHash)
+	if err == nil && script != nil && script.Script != "" &&
+		script.verify(scriptHash) == nil {
+		if value, err := json.Marshal(script); err == nil {
+			c.storeOnDisk(diskScript, scriptHash, value)
+		}
+	}
+	return script, err
+}
+
+func (c *Client) fetchScript(
+	ctx context.Context,
+	scriptHash string,
 ) (script *Script, err error) {
 	start := time.Now()
 	defer func() {
@@ -157,13 +236,9 @@ func (c *Client) Script(
 		return nil, fmt.Errorf("unable to build request: %w", err)
 	}
 
-	req.Close = true
 	req = req.WithContext(ctx)
 
-	client := &http.Client{
-		Timeout: c.options.timeout,
-	}
-	resp, err := client.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("unable to fetch script: %w", err)
 	}
@@ -171,9 +246,9 @@ func (c *Client) Script(
 		return nil, errors.New("failed with a nil response")
 	}
 	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+	body, err := readResponse(resp)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %w", err)
+		return nil, err
 	}
 
 	response := &Script{}
@@ -21,12 +21,18 @@
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
 // SOFTWARE.
 
-package kugo
+package kugo_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/SundaeSwap-finance/kugo"
+	"github.com/SundaeSwap-finance/kugo/kugotest"
 	"github.com/tj/assert"
 )
 
@@ -36,14 +42,14 @@ func TestClient_Scripts(t *testing.T) {
 		func(t *testing.T) {
 			t.Parallel()
 
-			script := Script{
-				Language: ScriptLanguagePlutusV2,
+			script := kugo.Script{
+				Language: kugo.ScriptLanguagePlutusV2,
 				Script:   "8201838200581c3c07030e36bfffe67e2e2ec09e5293d384637cd2f004356ef320f3fe8204186482051896",
 			}
-			server := NewMockServer().AddScripts(script).HTTP()
+			server := kugotest.New().AddScripts(script).HTTP()
 			defer server.Close()
 
-			client := New(WithEndpoint(server.URL))
+			client := kugo.New(kugo.WithEndpoint(server.URL))
 			scriptResponse, err := client.Script(
 				context.Background(),
 				"7031704ad63598d8d6bbc33550c0bb570f002fc9a46c7e1844e791d1",
@@ -59,10 +65,10 @@ func TestClient_Scripts(t *testing.T) {
 		func(t *testing.T) {
 			t.Parallel()
 
-			server := NewMockServer().HTTP()
+			server := kugotest.New().HTTP()
 			defer server.Close()
 
-			client := New(WithEndpoint(server.URL))
+			client := kugo.New(kugo.WithEndpoint(server.URL))
 			scriptResponse, err := client.Script(
 				context.Background(),
 				"4fc6bb0c93780ad706425d9f7dc1d3c5e3ddbf29ba8486dce904a5fc",
@@ -72,3 +78,78 @@ func TestClient_Scripts(t *testing.T) {
 		},
 	)
 }
+
+func TestMatch_ReferenceScript(t *testing.T) {
+	t.Parallel()
+
+	const scriptHash = "7031704ad63598d8d6bbc33550c0bb570f002fc9a46c7e1844e791d1"
+	script := kugo.Script{
+		Language: kugo.ScriptLanguagePlutusV2,
+		Script:   "8201838200581c3c07030e36bfffe67e2e2ec09e5293d384637cd2f004356ef320f3fe8204186482051896",
+	}
+	server := kugotest.New().AddScripts(script).HTTP()
+	defer server.Close()
+	client := kugo.New(kugo.WithEndpoint(server.URL))
+	ctx := context.Background()
+
+	// Embedded scripts are verified without a request
+	got, err := kugo.Match{
+		ScriptHash: scriptHash,
+		Script:     script,
+	}.ReferenceScript(
+		ctx,
+		nil,
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, script, *got)
+
+	got, err = kugo.Match{ScriptHash: scriptHash, ResolvedScript: script}.
+		ReferenceScript(ctx, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, script, *got)
+
+	got, err = kugo.Match{ScriptHash: scriptHash}.ReferenceScript(ctx, client)
+	assert.Nil(t, err)
+	assert.Equal(t, script, *got)
+
+	got, err = kugo.Match{}.ReferenceScript(ctx, client)
+	assert.Nil(t, err)
+	assert.Nil(t, got)
+
+	_, err = kugo.Match{
+		ScriptHash: "4fc6bb0c93780ad706425d9f7dc1d3c5e3ddbf29ba8486dce904a5fc",
+	}.ReferenceScript(
+		ctx,
+		client,
+	)
+	assert.True(t, errors.Is(err, kugo.ErrNotFound))
+
+	tampered := script
+	tampered.Language = kugo.ScriptLanguagePlutusV1
+	_, err = kugo.Match{
+		ScriptHash: scriptHash,
+		Script:     tampered,
+	}.ReferenceScript(
+		ctx,
+		client,
+	)
+	assert.True(t, errors.Is(err, kugo.ErrIntegrity))
+	assert.Contains(t, err.Error(), "script integrity check failed")
+
+	// A misbehaving kupo serving the wrong script for a hash
+	liar := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode(&tampered)
+		}),
+	)
+	defer liar.Close()
+	_, err = kugo.Match{
+		ScriptHash: scriptHash,
+	}.ReferenceScript(
+		ctx,
+		kugo.New(kugo.WithEndpoint(liar.URL)),
+	)
+	var integrityErr *kugo.IntegrityError
+	assert.True(t, errors.As(err, &integrityErr))
+	assert.Equal(t, scriptHash, integrityErr.Expected)
+}
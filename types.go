@@ -24,8 +24,13 @@
 package kugo
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 
+	"github.com/SundaeSwap-finance/kugo/address"
+	"github.com/SundaeSwap-finance/kugo/plutusdata"
 	"github.com/SundaeSwap-finance/ogmigo/v6/ouroboros/chainsync/num"
 	"github.com/SundaeSwap-finance/ogmigo/v6/ouroboros/shared"
 )
@@ -37,11 +42,74 @@ type Match struct {
 	Address          string  `json:"address,omitempty"`
 	DatumHash        string  `json:"datum_hash,omitempty"`
 	DatumType        string  `json:"datum_type,omitempty"`
+	Datum            string  `json:"datum,omitempty"` // hex encoded; see ResolveHashes and ResolveDatums
 	Value            Value   `json:"value,omitempty"`
 	CreatedAt        Point   `json:"created_at,omitempty"`
 	SpentAt          SpentAt `json:"spent_at,omitempty"`
 	ScriptHash       string  `json:"script_hash,omitempty"`
 	Script           Script  `json:"script,omitempty"`
+	// ResolvedScript is the script kupo resolved from ScriptHash when using
+	// ResolveHashes; Script is left for scripts kupo inlined in the match
+	ResolvedScript Script `json:"resolved_script,omitempty"`
+}
+
+// DecodeDatum unmarshals the match's datum into v; the datum must be inline
+// or already resolved with ResolveHashes or ResolveDatums
+func (m Match) DecodeDatum(v any) error {
+	if m.Datum == "" {
+		if m.DatumHash != "" {
+			return fmt.Errorf("datum %v has not been resolved", m.DatumHash)
+		}
+		return errors.New("match has no datum")
+	}
+	if err := plutusdata.UnmarshalHex(m.Datum, v); err != nil {
+		return fmt.Errorf("unable to decode datum: %w", err)
+	}
+	return nil
+}
+
+// ReferenceScript returns the reference script of the output, fetching it
+// from kupo when the match only carries its hash; the script is verified
+// against ScriptHash, returning an *IntegrityError on mismatch. It returns
+// nil if the output has no reference script.
+func (m Match) ReferenceScript(
+	ctx context.Context,
+	api API,
+) (*Script, error) {
+	if m.ScriptHash == "" {
+		if m.Script.Script == "" {
+			return nil, nil
+		}
+		script := m.Script
+		return &script, nil
+	}
+
+	script := &m.Script
+	if script.Script == "" {
+		script = &m.ResolvedScript
+	}
+	if script.Script == "" {
+		fetched, err := api.Script(ctx, m.ScriptHash)
+		if err != nil {
+			return nil, err
+		}
+		if fetched == nil || fetched.Script == "" {
+			return nil, fmt.Errorf("script %v: %w", m.ScriptHash, ErrNotFound)
+		}
+		script = fetched
+	}
+
+	if err := script.verify(m.ScriptHash); err != nil {
+		return nil, err
+	}
+	verified := *script
+	return &verified, nil
+}
+
+// ParsedAddress decodes the match's address into its network, type and
+// credentials; Byron addresses are identified, but otherwise opaque
+func (m Match) ParsedAddress() (address.Address, error) {
+	return address.Parse(m.Address)
 }
 
 type Value shared.Value
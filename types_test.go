@@ -5,6 +5,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/SundaeSwap-finance/kugo/address"
 	"github.com/tj/assert"
 )
 
@@ -47,3 +48,62 @@ func Test_DecodeMatchScriptHash(t *testing.T) {
 		"abc",
 	)
 }
+
+func Test_DecodeMatchResolvedHashes(t *testing.T) {
+	var match Match
+	err := json.Unmarshal(
+		[]byte(`{"datum_hash": "abc", "datum_type": "hash", "datum": "d87980", "script": null}`),
+		&match,
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, "d87980", match.Datum)
+	assert.Equal(t, Script{}, match.Script)
+}
+
+func Test_MatchDecodeDatum(t *testing.T) {
+	type Flag struct {
+		Enabled bool
+	}
+
+	var flag Flag
+	err := Match{Datum: "d8799fd87a80ff"}.DecodeDatum(&flag)
+	assert.Nil(t, err)
+	assert.True(t, flag.Enabled)
+
+	err = Match{DatumHash: "abc", DatumType: "hash"}.DecodeDatum(&flag)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "not been resolved")
+
+	err = Match{}.DecodeDatum(&flag)
+	assert.NotNil(t, err)
+}
+
+func Test_MatchParsedAddress(t *testing.T) {
+	match := Match{Address: "addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl8"}
+	parsed, err := match.ParsedAddress()
+	assert.Nil(t, err)
+	assert.Equal(t, address.Mainnet, parsed.Network)
+	assert.Equal(t, address.TypeEnterprise, parsed.Type)
+	assert.Equal(t, address.KeyHash, parsed.Payment.Kind)
+	assert.Equal(t, "9493315cd92eb5d8c4304e67b7e16ae36d61d34502694657811a2c8e", parsed.Payment.String())
+	assert.Nil(t, parsed.Stake)
+
+	parsed, err = Match{Address: "Ae2tdPwUPEZFRbyhz3cpfC2CumGzNkFBN2L42rcUc2yjQpEkxDbkPodpMAi"}.ParsedAddress()
+	assert.Nil(t, err)
+	assert.Equal(t, address.TypeByron, parsed.Type)
+
+	_, err = Match{}.ParsedAddress()
+	assert.NotNil(t, err)
+}
+
+func Test_MatchParsedAddressScript(t *testing.T) {
+	parsed, err := Match{
+		Address: "addr1z8phkx6acpnf78fuvxn0mkew3l0fd058hzquvz7w36x4gten0d3vllmyqwsx5wktcd8cc3sq835lu7drv2xwl2wywfgs9yc0hh",
+	}.ParsedAddress()
+	assert.Nil(t, err)
+	assert.Equal(t, address.TypeBase, parsed.Type)
+	assert.Equal(t, address.ScriptHash, parsed.Payment.Kind)
+	assert.NotNil(t, parsed.Stake)
+	assert.Equal(t, address.KeyHash, parsed.Stake.Kind)
+	assert.Equal(t, "337b62cfff6403a06a3acbc34f8c46003c69fe79a3628cefa9c47251", parsed.Stake.String())
+}
@@ -0,0 +1,238 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sort"
+	"time"
+
+	"github.com/SundaeSwap-finance/ogmigo/v6"
+)
+
+type WatchEventType int
+
+const (
+	// UtxoCreated is emitted when a matching output is created
+	UtxoCreated WatchEventType = iota + 1
+	// UtxoSpent is emitted when a matching output is spent
+	UtxoSpent
+	// RolledBack is emitted when the chain forks; events after the fork
+	// point are no longer valid, and will be emitted again as the new chain
+	// is observed
+	RolledBack
+)
+
+func (t WatchEventType) String() string {
+	switch t {
+	case UtxoCreated:
+		return "created"
+	case UtxoSpent:
+		return "spent"
+	case RolledBack:
+		return "rolled back"
+	default:
+		return fmt.Sprintf("WatchEventType(%d)", int(t))
+	}
+}
+
+type WatchEvent struct {
+	Type WatchEventType
+	// Match is the output created or spent; unset for RolledBack
+	Match Match
+	// Rollback describes the fork; only set for RolledBack
+	Rollback *Rollback
+	// Slot is the slot at which the event happened
+	Slot uint64
+}
+
+// Watch polls kupo at the client's poll interval, yielding an event each
+// time an output matching filters is created or spent, in slot order, and
+// whenever the chain rolls back. Events start after the slot given by a
+// CreatedAfter filter, or after the most recent checkpoint if there is none;
+// the spent/unspent and other slot filters are managed by Watch. Errors are
+// yielded and polling continues until ctx is done or the caller stops
+// iterating.
+func (c *Client) Watch(
+	ctx context.Context,
+	filters ...MatchesFilter,
+) iter.Seq2[WatchEvent, error] {
+	return Watch(ctx, c, filters, c.pollOptions()...)
+}
+
+// Watch is like Client.Watch, for any API; opts set how often it polls
+func Watch(
+	ctx context.Context,
+	api API,
+	filters []MatchesFilter,
+	opts ...PollOption,
+) iter.Seq2[WatchEvent, error] {
+	return func(yield func(WatchEvent, error) bool) {
+		o := matchesOptions{}
+		for _, f := range filters {
+			f(&o)
+		}
+		cursor := o.created_after
+		// Rollbacks are reported as events, so the tracker needn't log them
+		tracker := NewChainTracker(
+			api,
+			0,
+			append(opts[:len(opts):len(opts)], PollLogger(ogmigo.NopLogger))...,
+		)
+
+		ticker := time.NewTicker(tracker.options.interval)
+		defer ticker.Stop()
+
+		started := cursor != 0
+		for {
+			if !started {
+				points, err := api.Checkpoints(ctx, Latest())
+				if err == nil && len(points) > 0 {
+					cursor = uint64(points[0].SlotNo)
+					started = true
+				} else if err != nil && ctx.Err() == nil {
+					if !yield(WatchEvent{}, fmt.Errorf("unable to find starting checkpoint: %w", err)) {
+						return
+					}
+				}
+			}
+
+			if started {
+				var (
+					events []WatchEvent
+					err    error
+				)
+				events, cursor, err = pollWatch(ctx, api, tracker, cursor, filters)
+				if err != nil && ctx.Err() == nil {
+					if !yield(WatchEvent{}, err) {
+						return
+					}
+				}
+				for _, event := range events {
+					if !yield(event, nil) {
+						return
+					}
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+// pollWatch gathers the events after cursor, returning the slot the next
+// poll should start after
+func pollWatch(
+	ctx context.Context,
+	api API,
+	tracker *ChainTracker,
+	cursor uint64,
+	filters []MatchesFilter,
+) ([]WatchEvent, uint64, error) {
+	var events []WatchEvent
+
+	rollback, err := tracker.Poll(ctx)
+	if err != nil {
+		return nil, cursor, err
+	}
+	if rollback != nil && uint64(rollback.ForkPoint.SlotNo) < cursor {
+		cursor = uint64(rollback.ForkPoint.SlotNo)
+		events = append(events, WatchEvent{
+			Type:     RolledBack,
+			Rollback: rollback,
+			Slot:     cursor,
+		})
+	}
+
+	query := func(extra ...MatchesFilter) ([]Match, uint64, error) {
+		var info ResponseInfo
+		matches, err := api.Matches(
+			RecordResponseInfo(ctx, &info),
+			append(append([]MatchesFilter{}, filters...), extra...)...,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !info.HasCheckpoint {
+			tip, ok := tracker.Tip()
+			if !ok {
+				return nil, 0, fmt.Errorf("kupo didn't report a checkpoint")
+			}
+			info.MostRecentCheckpoint = uint64(tip.SlotNo)
+		}
+		return matches, info.MostRecentCheckpoint, nil
+	}
+
+	reset := []MatchesFilter{
+		All(),
+		CreatedBefore(0),
+		CreatedAfter(0),
+		SpentBefore(0),
+		SpentAfter(0),
+	}
+	created, createdCheckpoint, err := query(append(reset, CreatedAfter(cursor))...)
+	if err != nil {
+		return events, cursor, fmt.Errorf("unable to fetch created matches: %w", err)
+	}
+	spent, spentCheckpoint, err := query(append(reset, SpentAfter(cursor))...)
+	if err != nil {
+		return events, cursor, fmt.Errorf("unable to fetch spent matches: %w", err)
+	}
+
+	// Only report events both queries agree on; anything later is picked up
+	// on the next poll
+	until := min(createdCheckpoint, spentCheckpoint)
+	if until < cursor {
+		return events, cursor, nil
+	}
+
+	var changes []WatchEvent
+	for _, match := range created {
+		slot := uint64(match.CreatedAt.SlotNo)
+		if slot > cursor && slot <= until {
+			changes = append(changes, WatchEvent{Type: UtxoCreated, Match: match, Slot: slot})
+		}
+	}
+	for _, match := range spent {
+		slot := uint64(match.SpentAt.SlotNo)
+		if slot > cursor && slot <= until {
+			changes = append(changes, WatchEvent{Type: UtxoSpent, Match: match, Slot: slot})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Slot != b.Slot {
+			return a.Slot < b.Slot
+		}
+		return a.Type < b.Type
+	})
+
+	return append(events, changes...), until, nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2022 SundaeSwap Labs, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software
+// is furnished to do so, subject to the following conditions:
+//
+// Licensed under the MIT License;
+// You may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    https://opensource.org/licenses/MIT
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package kugo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SundaeSwap-finance/kugo"
+	"github.com/SundaeSwap-finance/kugo/kugotest"
+	"github.com/tj/assert"
+)
+
+func Test_Watch(t *testing.T) {
+	t.Parallel()
+	created := func(index int) kugo.Match {
+		return kugo.Match{
+			TransactionID: "abcdef",
+			OutputIndex:   index,
+			Address:       address,
+		}
+	}
+
+	fake := kugotest.New().
+		RollForward(kugo.Point{SlotNo: 5, HeaderHash: "5"}, created(9)).
+		RollForward(kugo.Point{SlotNo: 10, HeaderHash: "a"}).
+		RollForward(kugo.Point{SlotNo: 12, HeaderHash: "12"}, created(0)).
+		RollForward(kugo.Point{SlotNo: 15, HeaderHash: "15"}, created(1)).
+		RollForward(kugo.Point{SlotNo: 18, HeaderHash: "18"}).
+		RollForward(kugo.Point{SlotNo: 20, HeaderHash: "b"})
+	fake.Spend("abcdef", 1, kugo.SpentAt{SlotNo: 18, HeaderHash: "18"})
+	server := fake.HTTP()
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client := kugo.New(
+		kugo.WithEndpoint(server.URL),
+		kugo.WithPollInterval(time.Millisecond),
+	)
+
+	type event struct {
+		Type  kugo.WatchEventType
+		Index int
+		Slot  uint64
+	}
+	var events []event
+	for e, err := range client.Watch(ctx, kugo.Address(address), kugo.CreatedAfter(10)) {
+		assert.Nil(t, err)
+		events = append(
+			events,
+			event{Type: e.Type, Index: e.Match.OutputIndex, Slot: e.Slot},
+		)
+		switch len(events) {
+		case 3:
+			fake.
+				RollForward(
+					kugo.Point{SlotNo: 25, HeaderHash: "25"},
+					created(2),
+				).
+				RollForward(kugo.Point{SlotNo: 30, HeaderHash: "c"})
+		case 4:
+			// A fork replaces the blocks after 20, including 2 again
+			fake.
+				RollBackward(20).
+				RollForward(kugo.Point{SlotNo: 25, HeaderHash: "25'"}, created(2)).
+				RollForward(kugo.Point{SlotNo: 31, HeaderHash: "c'"})
+		}
+		if len(events) == 6 {
+			break
+		}
+	}
+	assert.Equal(
+		t,
+		[]event{
+			{Type: kugo.UtxoCreated, Index: 0, Slot: 12},
+			{Type: kugo.UtxoCreated, Index: 1, Slot: 15},
+			{Type: kugo.UtxoSpent, Index: 1, Slot: 18},
+			{Type: kugo.UtxoCreated, Index: 2, Slot: 25},
+			{Type: kugo.RolledBack, Slot: 20},
+			{Type: kugo.UtxoCreated, Index: 2, Slot: 25},
+		},
+		events,
+	)
+}